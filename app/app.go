@@ -0,0 +1,137 @@
+// Package app 組裝伺服器所需的依賴（資料庫、Session Store、配置與 Repository），
+// 由 main 建立後交給 routes 與 controllers 使用，取代原本分散在各套件的全局變數。
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"http-server/config"
+	"http-server/database"
+	"http-server/metrics"
+	"http-server/models"
+	"http-server/sessionstore"
+	"log/slog"
+
+	"github.com/gorilla/sessions"
+)
+
+// App 保存伺服器所需的所有依賴
+type App struct {
+	DB        *sql.DB
+	Sessions  sessionstore.Store
+	Config    *config.ConfigManager
+	JWTSecret []byte
+	Metrics   *metrics.Metrics
+
+	Users           models.UserRepository
+	Profiles        models.ProfileRepository
+	Roles           models.RoleRepository
+	Items           models.ItemRepository
+	Configs         models.ConfigRepository
+	PasswordHistory models.PasswordHistoryRepository
+	Accounts        models.AccountRepository
+	RefreshTokens   models.RefreshTokenRepository
+}
+
+// New 依照設定連線資料庫，並建立 Session Store 與 ConfigManager
+// 設定 DEMO_MODE=true 時不連線資料庫，改用 NewDemo 建立的記憶體版本
+func New(cfg *config.Config) (*App, error) {
+	if cfg.DemoMode {
+		return NewDemo(cfg)
+	}
+
+	db, err := database.InitDB(cfg.Database.DSN, cfg.Database.Options())
+	if err != nil {
+		return nil, err
+	}
+
+	a := &App{
+		DB:              db,
+		Users:           models.NewSQLUserRepository(db),
+		Profiles:        models.NewSQLProfileRepository(db),
+		Roles:           models.NewSQLRoleRepository(db),
+		Items:           models.NewSQLItemRepository(db),
+		Configs:         models.NewSQLConfigRepository(db),
+		PasswordHistory: models.NewSQLPasswordHistoryRepository(db),
+		Accounts:        models.NewSQLAccountRepository(db),
+		RefreshTokens:   models.NewSQLRefreshTokenRepository(db),
+		Metrics:         metrics.New(db),
+	}
+
+	a.Config, err = config.NewConfigManager(a.Configs, cfg.Runtime)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	config.SetDefault(a.Config)
+
+	if err := cfg.Session.Validate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Session 金鑰設定錯誤: %w", err)
+	}
+	for _, err := range cfg.Session.WeakFallbackKeys() {
+		slog.Warn("舊的 Session 金鑰強度不足，舊的 Session 過期後請移除", "err", err)
+	}
+	if !cfg.Session.Encrypted() {
+		slog.Warn("未設定 SESSION_ENCRYPTION_KEYS，Session Cookie 只簽名不加密")
+	}
+	a.JWTSecret = cfg.Session.JWTSecret
+	a.Sessions, err = newSessionStore(db, cfg.Session)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// newSessionStore 依照設定建立 Session Store
+func newSessionStore(db *sql.DB, c config.SessionConfig) (sessionstore.Store, error) {
+	options := &sessions.Options{
+		Path:     "/",
+		MaxAge:   c.MaxAge, // 設置存活時間（秒）
+		HttpOnly: true,     // 禁止 JavaScript 訪問
+		Secure:   c.Secure, // 只透過 HTTPS 傳送
+	}
+
+	switch c.Store {
+	case "memory":
+		store := sessionstore.NewMemoryStore(c.Pairs()...)
+		store.Options = options
+		return store, nil
+	case "mysql":
+		slog.Warn("SESSION_STORE=mysql 已棄用，請改用 sql")
+		fallthrough
+	case "sql":
+		store := sessionstore.NewSQLStore(models.NewSQLSessionRepository(db), c.Pairs()...)
+		store.Options = options
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown SESSION_STORE: %s", c.Store)
+	}
+}
+
+// DBStats 返回資料庫連線池的統計，展示模式沒有資料庫時 ok 為 false
+func (a *App) DBStats() (stats database.PoolStats, ok bool) {
+	if a.DB == nil {
+		return stats, false
+	}
+	return database.Stats(a.DB), true
+}
+
+// Close 關閉 Session Store 與資料庫連線池
+func (a *App) Close() error {
+	var errs []error
+	if a.Sessions != nil {
+		if err := a.Sessions.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("session store: %w", err))
+		}
+	}
+	if a.DB != nil {
+		if err := a.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("database: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"crypto/rand"
+	"http-server/config"
+	"http-server/metrics"
+	"http-server/models"
+	"http-server/sessionstore"
+	"log/slog"
+
+	"github.com/gorilla/sessions"
+)
+
+// demoRoles 是展示模式中可用的角色，新註冊的用戶使用預設角色 87
+var demoRoles = []models.Role{
+	{ID: 1, Name: "admin", Description: "Administrator", Permissions: []string{"admin", "items:read", "items:write", "profile:write"}},
+	{ID: 87, Name: "user", Description: "Registered user", Permissions: []string{"items:read", "items:write", "profile:write"}},
+}
+
+// NewDemo 建立不連線資料庫的 App，所有資料保存在記憶體中，伺服器重啟後全部清除
+// 未設定 SESSION_AUTH_KEYS 時會產生隨機金鑰，重啟後所有 Session 與 JWT 都會失效
+func NewDemo(cfg *config.Config) (*App, error) {
+	users := models.NewMemoryUserRepository()
+	profiles := models.NewMemoryProfileRepository(users)
+	a := &App{
+		Users:           users,
+		Profiles:        profiles,
+		Roles:           models.NewMemoryRoleRepository(demoRoles...),
+		Items:           models.NewMemoryItemRepository(),
+		Configs:         models.NewMemoryConfigRepository(nil),
+		PasswordHistory: models.NewMemoryPasswordHistoryRepository(),
+		Accounts:        models.NewMemoryAccountRepository(users, profiles),
+		RefreshTokens:   models.NewMemoryRefreshTokenRepository(),
+		Metrics:         metrics.New(nil),
+	}
+
+	var err error
+	a.Config, err = config.NewConfigManager(a.Configs, cfg.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	config.SetDefault(a.Config)
+
+	sessionConfig := cfg.Session
+	if len(sessionConfig.KeyPairs) == 0 {
+		kp := config.KeyPair{Auth: make([]byte, 32), Encryption: make([]byte, 32)}
+		if _, err := rand.Read(kp.Auth); err != nil {
+			return nil, err
+		}
+		if _, err := rand.Read(kp.Encryption); err != nil {
+			return nil, err
+		}
+		sessionConfig.KeyPairs = []config.KeyPair{kp}
+	}
+	if len(sessionConfig.JWTSecret) == 0 {
+		sessionConfig.JWTSecret = sessionConfig.KeyPairs[0].Auth
+	}
+	a.JWTSecret = sessionConfig.JWTSecret
+
+	store := sessionstore.NewMemoryStore(sessionConfig.Pairs()...)
+	store.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   sessionConfig.MaxAge,
+		HttpOnly: true,
+		Secure:   sessionConfig.Secure,
+	}
+	a.Sessions = store
+
+	slog.Warn("展示模式：未連線資料庫，資料只保存在記憶體中")
+	return a, nil
+}
@@ -0,0 +1,112 @@
+// Package certs 負責載入 TLS 憑證，並在憑證檔案更新時自動重新載入，
+// 讓伺服器在更換憑證（例如 Let's Encrypt 續期）後不需要重啟。
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader 保存目前使用的憑證，透過 tls.Config.GetCertificate 提供給 TLS 握手使用
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certTime time.Time // 上次載入時憑證檔案的修改時間
+	keyTime  time.Time // 上次載入時私鑰檔案的修改時間
+}
+
+// NewReloader 載入憑證與私鑰，檔案無法讀取或格式錯誤時返回錯誤
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 返回目前的憑證，可直接設定為 tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch 每隔 interval 檢查憑證檔案的修改時間，有變更時重新載入，直到 ctx 結束
+// 重新載入失敗時（例如只更新了其中一個檔案）會繼續使用舊的憑證，並在下次檢查時重試
+// interval 小於等於 0 時不檢查，憑證更新後需要重啟
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := r.changed()
+			if err != nil {
+				slog.Error("TLS 憑證檢查失敗", "err", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				slog.Error("TLS 憑證重新載入失敗，繼續使用舊的憑證", "err", err)
+				continue
+			}
+			slog.Info("TLS 憑證已重新載入", "cert", r.certFile)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// changed 判斷憑證或私鑰檔案是否在上次載入後被修改
+func (r *Reloader) changed() (bool, error) {
+	certTime, keyTime, err := r.modTimes()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !certTime.Equal(r.certTime) || !keyTime.Equal(r.keyTime), nil
+}
+
+func (r *Reloader) reload() error {
+	certTime, keyTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.certTime = certTime
+	r.keyTime = keyTime
+	return nil
+}
+
+func (r *Reloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
@@ -0,0 +1,67 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloaderPicksUpNewCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := GenerateSelfSigned(certFile, keyFile, []string{"localhost"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	// 確保修改時間不同
+	time.Sleep(20 * time.Millisecond)
+	if err := GenerateSelfSigned(certFile, keyFile, []string{"localhost"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cert, _ := r.GetCertificate(&tls.ClientHelloInfo{}); cert != first {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("certificate was not reloaded")
+}
+
+func TestWatchNonPositiveInterval(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := GenerateSelfSigned(certFile, keyFile, []string{"localhost"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// interval 為 0 或負數時直接返回，不會建立 Ticker（否則會 panic）
+	for _, interval := range []time.Duration{0, -time.Second} {
+		done := make(chan struct{})
+		go func() {
+			r.Watch(context.Background(), interval)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Watch(%v) did not return", interval)
+		}
+	}
+}
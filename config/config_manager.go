@@ -1,88 +1,157 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"http-server/database"
 	"http-server/models"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
-
-	"github.com/gorilla/sessions"
-	"github.com/joho/godotenv"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // ConfigManager 是一個用於管理應用程序配置的結構體。
 // configMap 用於存儲配置的 key-value 鍵值對，
 // mu 是一個讀寫鎖，確保在多線程環境中對 configMap 的操作是安全的。
+// 呼叫 Reload 可以重新從 config 表載入，並通知透過 Subscribe 訂閱的函數。
 type ConfigManager struct {
+	repo      models.ConfigRepository
+	defaults  map[string]string
 	configMap map[string]string
-	mu        sync.RWMutex // 讀寫鎖，用於保護 configMap
+	loadedAt  time.Time    // 最後一次成功載入的時間
+	mu        sync.RWMutex // 讀寫鎖，用於保護 configMap 與 loadedAt
+
+	subscribers map[string][]func(old, new string)
+	subMu       sync.Mutex // 保護 subscribers
+	reloadMu    sync.Mutex // 避免多個 Reload 同時執行
 }
 
-// instance 是 ConfigManager 的全局唯一實例，保證配置的單例性。
-var instance *ConfigManager
+// defaultManager 是 Get 返回的 ConfigManager
+var defaultManager atomic.Pointer[ConfigManager]
 
-// once 用於確保 LoadConfig() 中的初始化邏輯只執行一次。
-var once sync.Once
+// Get 返回透過 SetDefault 設定的 ConfigManager，尚未設定時返回 nil
+// 供無法透過 App 注入依賴的地方使用
+func Get() *ConfigManager {
+	return defaultManager.Load()
+}
 
-var Store *sessions.CookieStore
+// SetDefault 設定 Get 返回的 ConfigManager
+func SetDefault(c *ConfigManager) {
+	defaultManager.Store(c)
+}
 
-// InitConfig 初始化所有配置
-func InitConfig() {
-	var err error
+// NewConfigManager 從 config 表加載配置，建立 ConfigManager
+// defaults 是在設定檔、環境變數或命令列參數中設定的 runtime key，會被 config 表中的值覆蓋
+func NewConfigManager(repo models.ConfigRepository, defaults map[string]string) (*ConfigManager, error) {
+	c := &ConfigManager{repo: repo, defaults: defaults, configMap: map[string]string{}}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	// 加載 .env 文件
-	err = godotenv.Load()
+// Reload 重新從 config 表加載配置，並通知值有變化（包含新增與刪除）的 key 的訂閱者
+// 載入失敗時保留原本的配置
+func (c *ConfigManager) Reload() error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	// 從資料庫加載配置
+	configs, err := c.repo.GetAllConfigs()
 	if err != nil {
-		// 如果 .env 文件無法加載，程序無法繼續執行
-		panic(fmt.Sprintf("Error loading .env file: %v", err))
+		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// 初始化資料庫
-	database.InitDB()
+	// 臨時存儲配置的鍵值對，config 表中的值覆蓋 defaults
+	configMap := make(map[string]string, len(c.defaults)+len(configs))
+	for key, value := range c.defaults {
+		configMap[key] = value
+	}
+	for _, config := range configs {
+		configMap[config.Key] = config.Value
+	}
 
-	// 初始化全局 Session Store
-	Store = sessions.NewCookieStore([]byte(os.Getenv("SECRET_KEY")))
-	Store.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   3600,  // 設置存活時間（秒）
-		HttpOnly: true,  // 禁止 JavaScript 訪問
+	c.mu.Lock()
+	old := c.configMap
+	c.configMap = configMap
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+
+	// 在鎖外通知訂閱者，讓訂閱函數可以讀取新的配置
+	for key := range old {
+		if value, ok := configMap[key]; !ok || value != old[key] {
+			c.notify(key, old[key], value)
+		}
 	}
+	for key, value := range configMap {
+		if _, ok := old[key]; !ok {
+			c.notify(key, "", value)
+		}
+	}
+	return nil
+}
 
-	err = LoadConfig()
-	if err != nil {
-		panic(fmt.Sprintf("Failed to do LoadConfig: %v", err))
+// LoadedAt 返回最後一次成功從 config 表載入的時間
+func (c *ConfigManager) LoadedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loadedAt
+}
+
+// Subscribe 註冊 key 的值改變時要呼叫的函數，old 與 new 在 key 不存在時為空字串
+// 函數在 Reload 所在的 goroutine 中依註冊順序呼叫
+func (c *ConfigManager) Subscribe(key string, fn func(old, new string)) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.subscribers == nil {
+		c.subscribers = make(map[string][]func(old, new string))
 	}
+	c.subscribers[key] = append(c.subscribers[key], fn)
+}
 
-	fmt.Println("Config initialized successfully")
+func (c *ConfigManager) notify(key, old, new string) {
+	c.subMu.Lock()
+	subscribers := append([]func(old, new string){}, c.subscribers[key]...)
+	c.subMu.Unlock()
+	for _, fn := range subscribers {
+		fn(old, new)
+	}
 }
 
-// LoadConfig 從資料庫加載配置，並初始化全局單例。
-// 如果已經加載過配置，則不會重複執行。
-func LoadConfig() error {
-	// once.Do 確保以下邏輯只執行一次，即使有多個線程同時調用 LoadConfig()。
-	once.Do(func() {
-		// 初始化 ConfigManager 實例
-		instance = &ConfigManager{configMap: make(map[string]string)}
-
-		// 從資料庫加載配置
-		configs, err := models.GetAllConfigs()
-		if err != nil {
-			// 如果查詢資料庫失敗，清空實例並拋出錯誤
-			instance = nil
-			panic(fmt.Errorf("failed to load config: %v", err))
-		}
+// Watch 在收到 SIGHUP 時重新載入配置，interval 大於 0 時也會定期重新載入，直到 ctx 結束
+func (c *ConfigManager) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
-		// 臨時存儲配置的鍵值對
-		tempMap := make(map[string]string)
-		for _, config := range configs {
-			tempMap[config.Key] = config.Value
+	for {
+		select {
+		case <-hup:
+			if err := c.Reload(); err != nil {
+				slog.Error("配置重新載入失敗，繼續使用舊的配置", "err", err)
+				continue
+			}
+			slog.Info("配置已重新載入")
+		case <-tick:
+			if err := c.Reload(); err != nil {
+				slog.Error("配置重新載入失敗，繼續使用舊的配置", "err", err)
+			}
+		case <-ctx.Done():
+			return
 		}
-
-		// 將臨時 map 賦值給 ConfigManager 實例的 configMap
-		instance.configMap = tempMap
-	})
-	return nil
+	}
 }
 
 // GetProperty 根據給定的 key 獲取配置值。
@@ -92,5 +161,45 @@ func (c *ConfigManager) GetProperty(key string) (string, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	value, exists := c.configMap[key] // 查找配置 key
-	return value, exists             // 返回結果和是否存在的標誌
-}
\ No newline at end of file
+	return value, exists              // 返回結果和是否存在的標誌
+}
+
+// 以下函數返回轉換後的配置值，未設定、為空或格式錯誤時返回預設值
+
+// GetString 返回 key 的值
+func (c *ConfigManager) GetString(key, def string) string {
+	if value, ok := c.GetProperty(key); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// GetInt 返回 key 的整數值
+func (c *ConfigManager) GetInt(key string, def int) int {
+	if value, ok := c.GetProperty(key); ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+// GetBool 返回 key 的布林值，接受 strconv.ParseBool 支援的格式（true、false、1、0 等）
+func (c *ConfigManager) GetBool(key string, def bool) bool {
+	if value, ok := c.GetProperty(key); ok {
+		if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// GetDuration 返回 key 的時間長度，格式同 time.ParseDuration（例如 15m、1h30m）
+func (c *ConfigManager) GetDuration(key string, def time.Duration) time.Duration {
+	if value, ok := c.GetProperty(key); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			return d
+		}
+	}
+	return def
+}
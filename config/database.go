@@ -0,0 +1,29 @@
+package config
+
+import (
+	"http-server/database"
+	"time"
+)
+
+// DatabaseConfig 是資料庫連線的設定
+type DatabaseConfig struct {
+	DSN             string        // 資料庫連線字串，scheme 決定使用的資料庫
+	AutoMigrate     bool          // 啟動時是否自動套用尚未套用的遷移
+	MaxOpenConns    int           // 連線池的最大連線數，0 表示不限制
+	MaxIdleConns    int           // 連線池保留的閒置連線數
+	ConnMaxLifetime time.Duration // 連線的最長使用時間，應小於資料庫的 wait_timeout
+	ConnMaxIdleTime time.Duration // 閒置連線關閉前的時間
+	ConnectTimeout  time.Duration // 啟動時持續重試連線的時間上限，0 表示只嘗試一次
+}
+
+// Options 返回 database.InitDB 使用的設定
+func (c DatabaseConfig) Options() database.Options {
+	return database.Options{
+		AutoMigrate:     c.AutoMigrate,
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+		ConnMaxIdleTime: c.ConnMaxIdleTime,
+		ConnectTimeout:  c.ConnectTimeout,
+	}
+}
@@ -0,0 +1,456 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"http-server/logging"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// 設定的來源，依優先順序由低到高排列；runtime key 最後會再被 config 表覆蓋
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = ".env"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+	SourceDB      = "db"
+)
+
+// Config 是啟動時載入的所有設定
+type Config struct {
+	Server               ServerConfig
+	Database             DatabaseConfig
+	Session              SessionConfig
+	DemoMode             bool          // 不連線資料庫，資料只保存在記憶體中
+	ConfigReloadInterval time.Duration // 定期重新載入 config 表的間隔，0 表示只在收到 SIGHUP 時重新載入
+	LogLevel             string        // 日誌等級：debug、info、warn 或 error
+	LogFormat            string        // 日誌格式：text 或 json
+
+	// Runtime 是在 config 表以外設定的 runtime key（見 registry.go），作為 ConfigManager 的預設值
+	Runtime map[string]string
+
+	values map[string]setting // 每個設定的最終值與來源，供 Print 使用
+}
+
+// setting 是一個設定的值與來源
+type setting struct {
+	value  string
+	source string
+}
+
+// settingDef 描述一個啟動設定，Key 同時是環境變數與 .env 的名稱
+type settingDef struct {
+	Key     string
+	Default string
+	Usage   string
+	Flag    string              // 命令列參數名稱，預設為小寫並以 - 連接的 Key
+	Redact  func(string) string // 不為 nil 時，Print 顯示的是處理後的值
+}
+
+// settingDefs 是所有啟動設定，設定檔中可以用巢狀的寫法（例如 http: {addr: ":8080"} 即 HTTP_ADDR）
+var settingDefs = []settingDef{
+	{Key: "CONFIG_FILE", Usage: "YAML（.yaml、.yml）或 TOML（.toml）設定檔的路徑", Flag: "config"},
+
+	{Key: "HTTP_ADDR", Default: ":8080", Usage: "監聽位址"},
+	{Key: "HTTP_READ_TIMEOUT", Default: "15s", Usage: "讀取整個請求（含 Body）的時間上限"},
+	{Key: "HTTP_READ_HEADER_TIMEOUT", Default: "5s", Usage: "讀取請求標頭的時間上限"},
+	{Key: "HTTP_WRITE_TIMEOUT", Default: "30s", Usage: "寫入響應的時間上限"},
+	{Key: "HTTP_IDLE_TIMEOUT", Default: "60s", Usage: "Keep-Alive 連線閒置的時間上限"},
+	{Key: "HTTP_MAX_HEADER_BYTES", Default: "1048576", Usage: "請求標頭的大小上限"},
+	{Key: "HTTP_SHUTDOWN_TIMEOUT", Default: "15s", Usage: "關閉伺服器時等待進行中請求的時間上限"},
+
+	{Key: "TLS_CERT_FILE", Usage: "憑證路徑，與 TLS_KEY_FILE 都設定時啟用 HTTPS"},
+	{Key: "TLS_KEY_FILE", Usage: "私鑰路徑"},
+	{Key: "TLS_SELF_SIGNED", Default: "false", Usage: "憑證不存在時自動產生自簽憑證（僅供本機測試）"},
+	{Key: "TLS_SELF_SIGNED_HOSTS", Default: "localhost,127.0.0.1", Usage: "自簽憑證包含的網域名稱或 IP，以逗號分隔"},
+	{Key: "TLS_RELOAD_INTERVAL", Default: "1m", Usage: "檢查憑證檔案是否更新的間隔，0 表示不檢查"},
+	{Key: "TLS_REDIRECT_ADDR", Usage: "在此位址監聽 HTTP 並重定向到 HTTPS，例如 :80"},
+
+	{Key: "METRICS_ADDR", Usage: "在此位址另外提供不需要登入的 /metrics，例如 127.0.0.1:9090；主要位址的 /metrics 需要 admin 權限"},
+
+	{Key: "DATABASE_DSN", Usage: "資料庫連線字串，scheme 決定資料庫：mysql://（需要包含 parseTime=true）、postgres:// 或 sqlite://", Redact: redactDSN},
+	{Key: "DB_AUTO_MIGRATE", Default: "false", Usage: "啟動時自動套用尚未套用的遷移"},
+	{Key: "DB_MAX_OPEN_CONNS", Default: "25", Usage: "連線池的最大連線數，0 表示不限制（SQLite 固定為 1）"},
+	{Key: "DB_MAX_IDLE_CONNS", Default: "10", Usage: "連線池保留的閒置連線數"},
+	{Key: "DB_CONN_MAX_LIFETIME", Default: "5m", Usage: "連線的最長使用時間，應小於資料庫的 wait_timeout"},
+	{Key: "DB_CONN_MAX_IDLE_TIME", Default: "1m", Usage: "閒置連線關閉前的時間"},
+	{Key: "DB_CONNECT_TIMEOUT", Default: "30s", Usage: "啟動時資料庫無法連線會持續重試（間隔逐次加倍）的時間上限，0 表示只嘗試一次"},
+
+	{Key: "SESSION_STORE", Default: "sql", Usage: "Session Store 類型：sql（存在資料庫的 sessions 表）或 memory；mysql 為 sql 的舊名稱"},
+	{Key: "SESSION_MAX_AGE", Default: "3600", Usage: "Session 存活時間（秒）"},
+	{Key: "SESSION_COOKIE_SECURE", Usage: "Cookie 只透過 HTTPS 傳送，未設定時在直接提供 HTTPS 時啟用"},
+	{Key: "SESSION_AUTH_KEYS", Usage: "簽名 Session Cookie 的金鑰（至少 32 bytes），以逗號分隔，新的在前；可使用 base64: 或 hex: 前綴", Redact: redactKeys},
+	{Key: "SESSION_ENCRYPTION_KEYS", Usage: "加密 Session Cookie 的金鑰（16、24 或 32 bytes），與 SESSION_AUTH_KEYS 依序配對", Redact: redactKeys},
+	{Key: "SECRET_KEY", Usage: "舊的 Session 金鑰，設定 SESSION_AUTH_KEYS 後只用來解開舊的 Cookie", Redact: redactSecret},
+	{Key: "JWT_SECRET", Usage: "簽發 JWT 的金鑰（至少 32 bytes），未設定時沿用第一把 Session 驗證金鑰", Redact: redactSecret},
+
+	{Key: "DEMO_MODE", Default: "false", Usage: "不連線資料庫，資料只保存在記憶體中"},
+	{Key: "CONFIG_RELOAD_INTERVAL", Default: "0s", Usage: "定期重新載入 config 表的間隔，0 表示只在收到 SIGHUP 時重新載入"},
+
+	{Key: "LOG_LEVEL", Default: "info", Usage: "日誌等級：debug、info、warn 或 error"},
+	{Key: "LOG_FORMAT", Default: "text", Usage: "日誌格式：text 或 json（適合交給日誌收集系統）"},
+}
+
+// flagName 返回設定的命令列參數名稱
+func (d settingDef) flagName() string {
+	if d.Flag != "" {
+		return d.Flag
+	}
+	return strings.ToLower(strings.ReplaceAll(d.Key, "_", "-"))
+}
+
+// runtimeEnvKey 將 runtime key 轉成環境變數名稱，例如 password.min_length 對應 PASSWORD_MIN_LENGTH
+func runtimeEnvKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// allSettingDefs 返回啟動設定與 runtime key 的定義
+func allSettingDefs() []settingDef {
+	defs := append([]settingDef{}, settingDefs...)
+	for _, def := range KnownKeys() {
+		defs = append(defs, settingDef{Key: runtimeEnvKey(def.Key), Usage: def.Description})
+	}
+	return defs
+}
+
+// Load 依照以下順序合併設定，後面的來源會覆蓋前面的：
+//
+//	預設值 < 設定檔（CONFIG_FILE 或 --config）< .env < 環境變數 < 命令列參數
+//
+// runtime key（見 registry.go）啟動後還會被 config 表覆蓋。
+// args 為命令列參數（不含程式名稱），返回參數之後剩下的子命令與其參數。
+func Load(args []string) (*Config, []string, error) {
+	defs := allSettingDefs()
+
+	// 命令列參數
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flagValues := make(map[string]*string, len(defs))
+	for _, def := range defs {
+		flagValues[def.Key] = fs.String(def.flagName(), "", def.Usage)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: %s [參數] [migrate <up|down [N]|status> | config print]\n\n", fs.Name())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	flags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		for _, def := range defs {
+			if def.flagName() == f.Name {
+				flags[def.Key] = *flagValues[def.Key]
+			}
+		}
+	})
+
+	// .env 文件，不存在時略過；不會寫入環境變數，讓真正的環境變數優先
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	env := make(map[string]string)
+	for _, def := range defs {
+		if value, ok := os.LookupEnv(def.Key); ok && value != "" {
+			env[def.Key] = value
+		}
+	}
+
+	// 設定檔的路徑本身只能來自 .env、環境變數或命令列參數
+	var file map[string]string
+	configFile := firstNonEmpty(flags["CONFIG_FILE"], env["CONFIG_FILE"], dotenv["CONFIG_FILE"])
+	if configFile != "" {
+		file, err = readConfigFile(configFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		delete(file, "CONFIG_FILE")
+	}
+
+	known := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		known[def.Key] = true
+	}
+	for key := range file {
+		if !known[key] {
+			return nil, nil, fmt.Errorf("unknown setting %s in %s", key, configFile)
+		}
+	}
+
+	values := make(map[string]setting, len(defs))
+	for _, def := range defs {
+		if def.Default != "" {
+			values[def.Key] = setting{value: def.Default, source: SourceDefault}
+		}
+	}
+	for _, layer := range []struct {
+		source string
+		values map[string]string
+	}{
+		{SourceFile, file},
+		{SourceDotEnv, dotenv},
+		{SourceEnv, env},
+		{SourceFlag, flags},
+	} {
+		for key, value := range layer.values {
+			if known[key] && value != "" {
+				values[key] = setting{value: value, source: layer.source}
+			}
+		}
+	}
+
+	cfg, err := newConfig(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// newConfig 將合併後的設定轉成 Config，所有格式錯誤會一起返回
+func newConfig(values map[string]setting) (*Config, error) {
+	r := &resolver{values: values}
+	cfg := &Config{
+		Server: ServerConfig{
+			Addr:              r.string("HTTP_ADDR"),
+			ReadTimeout:       r.duration("HTTP_READ_TIMEOUT"),
+			ReadHeaderTimeout: r.duration("HTTP_READ_HEADER_TIMEOUT"),
+			WriteTimeout:      r.duration("HTTP_WRITE_TIMEOUT"),
+			IdleTimeout:       r.duration("HTTP_IDLE_TIMEOUT"),
+			MaxHeaderBytes:    r.int("HTTP_MAX_HEADER_BYTES"),
+			ShutdownTimeout:   r.duration("HTTP_SHUTDOWN_TIMEOUT"),
+
+			TLSCertFile:        r.string("TLS_CERT_FILE"),
+			TLSKeyFile:         r.string("TLS_KEY_FILE"),
+			TLSSelfSigned:      r.bool("TLS_SELF_SIGNED"),
+			TLSSelfSignedHosts: strings.Split(r.string("TLS_SELF_SIGNED_HOSTS"), ","),
+			TLSReloadInterval:  r.duration("TLS_RELOAD_INTERVAL"),
+			TLSRedirectAddr:    r.string("TLS_REDIRECT_ADDR"),
+			MetricsAddr:        r.string("METRICS_ADDR"),
+		},
+		Database: DatabaseConfig{
+			DSN:             r.string("DATABASE_DSN"),
+			AutoMigrate:     r.bool("DB_AUTO_MIGRATE"),
+			MaxOpenConns:    r.int("DB_MAX_OPEN_CONNS"),
+			MaxIdleConns:    r.int("DB_MAX_IDLE_CONNS"),
+			ConnMaxLifetime: r.duration("DB_CONN_MAX_LIFETIME"),
+			ConnMaxIdleTime: r.duration("DB_CONN_MAX_IDLE_TIME"),
+			ConnectTimeout:  r.duration("DB_CONNECT_TIMEOUT"),
+		},
+		Session: SessionConfig{
+			Store:  r.string("SESSION_STORE"),
+			MaxAge: r.int("SESSION_MAX_AGE"),
+		},
+		DemoMode:             r.bool("DEMO_MODE"),
+		ConfigReloadInterval: r.duration("CONFIG_RELOAD_INTERVAL"),
+		LogLevel:             r.string("LOG_LEVEL"),
+		LogFormat:            r.string("LOG_FORMAT"),
+		Runtime:              make(map[string]string),
+		values:               values,
+	}
+
+	// 只設定其中一個憑證路徑時不會啟用 HTTPS，避免在未察覺的情況下改用 HTTP
+	if (cfg.Server.TLSCertFile == "") != (cfg.Server.TLSKeyFile == "") {
+		r.errs = append(r.errs, errors.New("TLS_CERT_FILE 與 TLS_KEY_FILE 必須同時設定"))
+	}
+	if cfg.Server.TLSSelfSigned && !cfg.Server.TLSEnabled() {
+		r.errs = append(r.errs, errors.New("TLS_SELF_SIGNED 需要設定 TLS_CERT_FILE 與 TLS_KEY_FILE（自簽憑證的儲存位置）"))
+	}
+
+	// 未設定 SESSION_COOKIE_SECURE 時，直接提供 HTTPS 就只透過 HTTPS 傳送 Cookie
+	cfg.Session.Secure = cfg.Server.TLSEnabled()
+	if _, ok := values["SESSION_COOKIE_SECURE"]; ok {
+		cfg.Session.Secure = r.bool("SESSION_COOKIE_SECURE")
+	}
+
+	keyPairs, err := sessionKeyPairs(r.string("SESSION_AUTH_KEYS"), r.string("SESSION_ENCRYPTION_KEYS"), r.string("SECRET_KEY"))
+	if err != nil {
+		r.errs = append(r.errs, err)
+	}
+	cfg.Session.KeyPairs = keyPairs
+	if jwtSecret := r.string("JWT_SECRET"); jwtSecret != "" {
+		if cfg.Session.JWTSecret, err = parseKey(jwtSecret); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("JWT_SECRET: %w", err))
+		}
+	} else if len(keyPairs) > 0 {
+		cfg.Session.JWTSecret = keyPairs[0].Auth
+	}
+
+	if _, err := logging.New(io.Discard, cfg.LogLevel, cfg.LogFormat); err != nil {
+		r.errs = append(r.errs, err)
+	}
+
+	for _, def := range KnownKeys() {
+		s, ok := values[runtimeEnvKey(def.Key)]
+		if !ok {
+			continue
+		}
+		if err := def.Validate(s.value); err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s (%s): %w", runtimeEnvKey(def.Key), s.source, err))
+			continue
+		}
+		cfg.Runtime[def.Key] = s.value
+	}
+
+	if err := errors.Join(r.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolver 將設定值轉成需要的類型，並記錄格式錯誤
+type resolver struct {
+	values map[string]setting
+	errs   []error
+}
+
+func (r *resolver) string(key string) string {
+	return r.values[key].value
+}
+
+func (r *resolver) int(key string) int {
+	s := r.values[key]
+	n, err := strconv.Atoi(strings.TrimSpace(s.value))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s (%s) 必須是整數: %q", key, s.source, s.value))
+	}
+	return n
+}
+
+func (r *resolver) bool(key string) bool {
+	s, ok := r.values[key]
+	if !ok {
+		return false
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(s.value))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s (%s) 必須是 true 或 false: %q", key, s.source, s.value))
+	}
+	return b
+}
+
+func (r *resolver) duration(key string) time.Duration {
+	s := r.values[key]
+	d, err := time.ParseDuration(strings.TrimSpace(s.value))
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s (%s) 必須是時間長度（例如 30s）: %q", key, s.source, s.value))
+	}
+	return d
+}
+
+// readConfigFile 讀取 YAML 或 TOML 設定檔，並將巢狀的 key 攤平成環境變數的名稱
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &tree)
+	case ".toml":
+		err = toml.Unmarshal(content, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten(values, "", tree)
+	return values, nil
+}
+
+// flatten 將巢狀的設定攤平，例如 http.read_timeout 對應 HTTP_READ_TIMEOUT，列表以逗號連接
+func flatten(dst map[string]string, prefix string, tree map[string]interface{}) {
+	for key, value := range tree {
+		name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(dst, name, v)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			dst[name] = strings.Join(items, ",")
+		case nil:
+		default:
+			dst[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Print 列出所有設定的最終值與來源，金鑰與連線密碼會被遮蔽
+// db 為 config 表中的配置，會覆蓋 runtime key；未設定的 runtime key 使用程式中的預設值
+func (c *Config) Print(w io.Writer, db map[string]string) {
+	fmt.Fprintf(w, "# 優先順序：%s < %s < %s < %s < %s < %s（僅 runtime key）\n",
+		SourceDefault, SourceFile, SourceDotEnv, SourceEnv, SourceFlag, SourceDB)
+
+	for _, def := range settingDefs {
+		s, ok := c.values[def.Key]
+		value, source := s.value, s.source
+		if !ok {
+			source = "unset"
+		} else if def.Redact != nil {
+			value = def.Redact(value)
+		}
+		fmt.Fprintf(w, "%-28s %-40s (%s)\n", def.Key, value, source)
+	}
+
+	fmt.Fprintln(w)
+	for _, def := range KnownKeys() {
+		value, source := "", "program default"
+		if s, ok := c.values[runtimeEnvKey(def.Key)]; ok {
+			value, source = s.value, s.source
+		}
+		if v, ok := db[def.Key]; ok {
+			value, source = v, SourceDB
+		}
+		fmt.Fprintf(w, "%-28s %-40s (%s)\n", def.Key, value, source)
+	}
+}
+
+// redactSecret 只顯示金鑰是否已設定與長度
+func redactSecret(value string) string {
+	return fmt.Sprintf("****** (%d bytes)", len(value))
+}
+
+// redactKeys 只顯示金鑰的數量
+func redactKeys(value string) string {
+	return fmt.Sprintf("****** (%d keys)", len(strings.Split(value, ",")))
+}
+
+// dsnPassword 匹配 DSN 中 user:password@ 的密碼部分，DSN 可以有 scheme（例如 postgres://）
+var dsnPassword = regexp.MustCompile(`^((?:[a-z0-9]+://)?[^:@/]*):([^@]*)@`)
+
+// redactDSN 遮蔽資料庫連線字串中的密碼
+func redactDSN(value string) string {
+	return dsnPassword.ReplaceAllString(value, "$1:******@")
+}
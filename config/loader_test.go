@@ -0,0 +1,145 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testAuthKey 是符合強度要求的 Session 驗證金鑰
+const testAuthKey = "hex:000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// loadInDir 在只包含 files 的暫存目錄中以 env 與 args 執行 Load
+// 工作目錄會切換到暫存目錄，讓 .env 與相對路徑的設定檔從該目錄讀取
+func loadInDir(t *testing.T, files map[string]string, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// 清除執行環境中可能存在的設定，只保留測試指定的值
+	for _, def := range allSettingDefs() {
+		t.Setenv(def.Key, "")
+	}
+	t.Setenv("SESSION_AUTH_KEYS", testAuthKey)
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	cfg, _, err := Load(args)
+	return cfg, err
+}
+
+func TestLoadTLSSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "plain HTTP", env: nil},
+		{name: "cert and key", env: map[string]string{"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem"}},
+		{name: "self-signed", env: map[string]string{"TLS_SELF_SIGNED": "true", "TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem"}},
+		{name: "cert without key", env: map[string]string{"TLS_CERT_FILE": "cert.pem"}, wantErr: "TLS_KEY_FILE"},
+		{name: "self-signed without paths", env: map[string]string{"TLS_SELF_SIGNED": "true"}, wantErr: "TLS_SELF_SIGNED"},
+		{name: "reload disabled", env: map[string]string{"TLS_CERT_FILE": "cert.pem", "TLS_KEY_FILE": "key.pem", "TLS_RELOAD_INTERVAL": "0s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadInDir(t, nil, tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want error mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadLayerOrder(t *testing.T) {
+	yamlFile := "http:\n  addr: \":1001\"\n"
+	dotenv := "HTTP_ADDR=:1002\n"
+
+	tests := []struct {
+		name       string
+		files      map[string]string
+		env        map[string]string
+		args       []string
+		want       string
+		wantSource string
+	}{
+		{name: "default", want: ":8080", wantSource: SourceDefault},
+		{name: "file", files: map[string]string{"config.yaml": yamlFile}, env: map[string]string{"CONFIG_FILE": "config.yaml"}, want: ":1001", wantSource: SourceFile},
+		{name: "toml file", files: map[string]string{"config.toml": "[http]\naddr = \":1001\"\n"}, args: []string{"--config", "config.toml"}, want: ":1001", wantSource: SourceFile},
+		{name: ".env over file", files: map[string]string{"config.yaml": yamlFile, ".env": dotenv}, env: map[string]string{"CONFIG_FILE": "config.yaml"}, want: ":1002", wantSource: SourceDotEnv},
+		{name: "env over .env", files: map[string]string{".env": dotenv}, env: map[string]string{"HTTP_ADDR": ":1003"}, want: ":1003", wantSource: SourceEnv},
+		{name: "flag over env", files: map[string]string{".env": dotenv}, env: map[string]string{"HTTP_ADDR": ":1003"}, args: []string{"--http-addr", ":1004"}, want: ":1004", wantSource: SourceFlag},
+		{name: "config file from .env", files: map[string]string{"config.yaml": yamlFile, ".env": "CONFIG_FILE=config.yaml\n"}, want: ":1001", wantSource: SourceFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadInDir(t, tt.files, tt.env, tt.args...)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Addr != tt.want {
+				t.Errorf("Addr = %q, want %q", cfg.Server.Addr, tt.want)
+			}
+			if source := cfg.values["HTTP_ADDR"].source; source != tt.wantSource {
+				t.Errorf("source = %q, want %q", source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoadRuntimeKeys(t *testing.T) {
+	files := map[string]string{"config.yaml": "password:\n  min_length: 10\n  history: 3\n"}
+	cfg, err := loadInDir(t, files, map[string]string{"CONFIG_FILE": "config.yaml", "PASSWORD_HISTORY": "7"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Runtime["password.min_length"]; got != "10" {
+		t.Errorf("password.min_length = %q, want %q", got, "10")
+	}
+	if got := cfg.Runtime["password.history"]; got != "7" {
+		t.Errorf("password.history = %q, want %q", got, "7")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "unknown key in file", files: map[string]string{"config.yaml": "htp:\n  addr: \":1\"\n"}, env: map[string]string{"CONFIG_FILE": "config.yaml"}, wantErr: "unknown setting HTP_ADDR"},
+		{name: "unsupported format", files: map[string]string{"config.ini": ""}, env: map[string]string{"CONFIG_FILE": "config.ini"}, wantErr: "unsupported config file format"},
+		{name: "invalid duration", env: map[string]string{"HTTP_READ_TIMEOUT": "soon"}, wantErr: "HTTP_READ_TIMEOUT"},
+		{name: "invalid runtime value", env: map[string]string{"PASSWORD_MIN_LENGTH": "many"}, wantErr: "PASSWORD_MIN_LENGTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadInDir(t, tt.files, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
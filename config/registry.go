@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyType 是 config 表中配置值的類型
+type KeyType string
+
+const (
+	TypeString   KeyType = "string"
+	TypeInt      KeyType = "int"
+	TypeBool     KeyType = "bool"
+	TypeDuration KeyType = "duration"
+)
+
+// KeyDefinition 描述一個可以在 config 表中設定的 key
+type KeyDefinition struct {
+	Key         string  `json:"key"`
+	Type        KeyType `json:"type"`
+	Description string  `json:"description"`
+}
+
+// registry 是所有已知的配置 key，透過管理 API 寫入時只接受這些 key
+// 新增讀取 config 表的功能時，需要一併在這裡登記
+var registry = map[string]KeyDefinition{}
+
+func register(key string, t KeyType, description string) {
+	registry[key] = KeyDefinition{Key: key, Type: t, Description: description}
+}
+
+func init() {
+	register("password.min_length", TypeInt, "密碼最短字元數")
+	register("password.max_length", TypeInt, "密碼最長位元組數（最多 72）")
+	register("password.require_upper", TypeBool, "密碼需要大寫字母")
+	register("password.require_lower", TypeBool, "密碼需要小寫字母")
+	register("password.require_digit", TypeBool, "密碼需要數字")
+	register("password.require_symbol", TypeBool, "密碼需要符號")
+	register("password.history", TypeInt, "不可與最近 N 次使用過的密碼相同，0 表示不檢查")
+	register("password.blocklist_file", TypeString, "常見／外洩密碼清單的路徑")
+
+	register("login.max_attempts", TypeInt, "同一用戶名連續失敗幾次後鎖定")
+	register("login.ip_max_attempts", TypeInt, "同一 IP 連續失敗幾次後鎖定")
+	register("login.backoff_base", TypeDuration, "第一次登入失敗後需要等待的時間，之後每次失敗加倍")
+	register("login.lockout_duration", TypeDuration, "登入鎖定時間")
+	register("login.trust_forwarded_for", TypeBool, "使用 X-Forwarded-For 最右邊的 IP 判斷來源 IP")
+}
+
+// LookupKey 查詢 key 的定義
+func LookupKey(key string) (KeyDefinition, bool) {
+	def, ok := registry[key]
+	return def, ok
+}
+
+// KnownKeys 返回所有已知 key 的定義，依 key 排序
+func KnownKeys() []KeyDefinition {
+	keys := make([]KeyDefinition, 0, len(registry))
+	for _, def := range registry {
+		keys = append(keys, def)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// Validate 檢查 value 是否符合 key 的類型，整數與時間長度不可為負數
+func (d KeyDefinition) Validate(value string) error {
+	value = strings.TrimSpace(value)
+	switch d.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", d.Key)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s must not be negative", d.Key)
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", d.Key)
+		}
+	case TypeDuration:
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 30s or 15m", d.Key)
+		}
+		if dur < 0 {
+			return fmt.Errorf("%s must not be negative", d.Key)
+		}
+	}
+	return nil
+}
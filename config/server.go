@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+)
+
+// ServerConfig 是 HTTP Server 的設定
+type ServerConfig struct {
+	Addr              string        // 監聽位址，例如 :8080
+	ReadTimeout       time.Duration // 讀取整個請求（含 Body）的時間上限
+	ReadHeaderTimeout time.Duration // 讀取請求標頭的時間上限
+	WriteTimeout      time.Duration // 寫入響應的時間上限
+	IdleTimeout       time.Duration // Keep-Alive 連線閒置的時間上限
+	MaxHeaderBytes    int           // 請求標頭的大小上限
+	ShutdownTimeout   time.Duration // 關閉伺服器時等待進行中請求的時間上限
+
+	TLSCertFile        string        // 憑證路徑，與 TLSKeyFile 都設定時啟用 HTTPS
+	TLSKeyFile         string        // 私鑰路徑
+	TLSSelfSigned      bool          // 憑證檔案不存在時自動產生自簽憑證（僅供本機測試）
+	TLSSelfSignedHosts []string      // 自簽憑證包含的網域名稱或 IP
+	TLSReloadInterval  time.Duration // 檢查憑證檔案是否更新的間隔
+	TLSRedirectAddr    string        // 不為空時在此位址監聽 HTTP，並重定向到 HTTPS
+
+	MetricsAddr string // 不為空時在此位址另外提供不需要登入的 /metrics
+}
+
+// TLSEnabled 判斷是否直接提供 HTTPS
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SessionConfig 是 Session 與 JWT 的設定
+type SessionConfig struct {
+	Store     string    // Session Store 類型：sql 或 memory
+	MaxAge    int       // Session 存活時間（秒）
+	Secure    bool      // Cookie 是否只透過 HTTPS 傳送
+	KeyPairs  []KeyPair // Cookie 的金鑰，第一組用於簽名與加密，其餘只用於解開輪替前發出的 Cookie
+	JWTSecret []byte    // 簽發與驗證 JWT 的金鑰
+}
+
+// KeyPair 是一組 Cookie 金鑰，Encryption 為 nil 時 Cookie 只簽名不加密
+type KeyPair struct {
+	Auth       []byte
+	Encryption []byte
+}
+
+// 金鑰長度的下限
+const (
+	minAuthKeyBytes   = 32
+	minJWTSecretBytes = 32
+)
+
+// Pairs 返回 securecookie.CodecsFromPairs 使用的格式：驗證金鑰、加密金鑰依序排列
+func (c SessionConfig) Pairs() [][]byte {
+	pairs := make([][]byte, 0, len(c.KeyPairs)*2)
+	for _, kp := range c.KeyPairs {
+		pairs = append(pairs, kp.Auth, kp.Encryption)
+	}
+	return pairs
+}
+
+// Encrypted 判斷新發出的 Cookie 是否會加密
+func (c SessionConfig) Encrypted() bool {
+	return len(c.KeyPairs) > 0 && c.KeyPairs[0].Encryption != nil
+}
+
+// Validate 檢查金鑰是否已設定且夠強，避免以空的或容易猜到的金鑰簽名 Cookie 與 JWT
+// 只有第一組金鑰用於簽名與加密，其餘的金鑰（輪替前的金鑰與 SECRET_KEY）只檢查加密金鑰的長度，
+// 強度不足時由 WeakFallbackKeys 列出，不阻擋啟動
+func (c SessionConfig) Validate() error {
+	if len(c.KeyPairs) == 0 {
+		return errors.New("SESSION_AUTH_KEYS 未設定，可以用 `config genkey` 產生")
+	}
+
+	var errs []error
+	primary := c.KeyPairs[0]
+	if err := checkKeyStrength(primary.Auth, minAuthKeyBytes); err != nil {
+		errs = append(errs, fmt.Errorf("第 1 組驗證金鑰%w", err))
+	}
+	if primary.Encryption != nil && validEncryptionKeyLength(primary.Encryption) {
+		if err := checkKeyStrength(primary.Encryption, len(primary.Encryption)); err != nil {
+			errs = append(errs, fmt.Errorf("第 1 組加密金鑰%w", err))
+		}
+	}
+	// AES 只接受這幾種長度，長度錯誤的金鑰連舊的 Cookie 都無法解開
+	for i, kp := range c.KeyPairs {
+		if kp.Encryption != nil && !validEncryptionKeyLength(kp.Encryption) {
+			errs = append(errs, fmt.Errorf("第 %d 組加密金鑰必須是 16、24 或 32 bytes，目前為 %d bytes", i+1, len(kp.Encryption)))
+		}
+	}
+	if err := checkKeyStrength(c.JWTSecret, minJWTSecretBytes); err != nil {
+		errs = append(errs, fmt.Errorf("JWT_SECRET %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// WeakFallbackKeys 列出強度不足、只用來解開舊 Cookie 的金鑰，供啟動時提出警告
+func (c SessionConfig) WeakFallbackKeys() []error {
+	var errs []error
+	for i := 1; i < len(c.KeyPairs); i++ {
+		kp := c.KeyPairs[i]
+		if err := checkKeyStrength(kp.Auth, minAuthKeyBytes); err != nil {
+			errs = append(errs, fmt.Errorf("第 %d 組驗證金鑰%w", i+1, err))
+		}
+		if kp.Encryption != nil && validEncryptionKeyLength(kp.Encryption) {
+			if err := checkKeyStrength(kp.Encryption, len(kp.Encryption)); err != nil {
+				errs = append(errs, fmt.Errorf("第 %d 組加密金鑰%w", i+1, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validEncryptionKeyLength 判斷加密金鑰是否為 AES-128、AES-192 或 AES-256 的長度
+func validEncryptionKeyLength(key []byte) bool {
+	n := len(key)
+	return n == 16 || n == 24 || n == 32
+}
+
+// checkKeyStrength 檢查金鑰長度，並拒絕重複字元組成的金鑰（例如 aaaa...）
+func checkKeyStrength(key []byte, minBytes int) error {
+	if len(key) < minBytes {
+		return fmt.Errorf("至少需要 %d bytes，目前為 %d bytes", minBytes, len(key))
+	}
+	distinct := make(map[byte]struct{})
+	for _, b := range key {
+		distinct[b] = struct{}{}
+	}
+	if len(distinct) < 8 {
+		return errors.New("太容易猜到，請使用隨機產生的金鑰")
+	}
+	return nil
+}
+
+// parseKeys 解析以逗號分隔的金鑰列表
+// 每把金鑰可以是 base64:...、hex:... 或直接使用字串的 bytes
+func parseKeys(value string) ([][]byte, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var keys [][]byte
+	for _, text := range strings.Split(value, ",") {
+		key, err := parseKey(strings.TrimSpace(text))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func parseKey(text string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(text, "base64:"):
+		key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, "base64:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 key: %w", err)
+		}
+		return key, nil
+	case strings.HasPrefix(text, "hex:"):
+		key, err := hex.DecodeString(strings.TrimPrefix(text, "hex:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex key: %w", err)
+		}
+		return key, nil
+	default:
+		return []byte(text), nil
+	}
+}
+
+// sessionKeyPairs 依照設定組合金鑰
+//
+//	SESSION_AUTH_KEYS        驗證金鑰，新的在前；輪替時把新金鑰加在最前面，舊金鑰保留到 Session 全部過期
+//	SESSION_ENCRYPTION_KEYS  加密金鑰，與 SESSION_AUTH_KEYS 依序配對，數量必須相同
+//	SECRET_KEY               舊的單一金鑰（只簽名不加密）。未設定 SESSION_AUTH_KEYS 時作為唯一的金鑰，
+//	                         否則只用來解開舊的 Cookie，讓改用新金鑰時不需要所有用戶重新登入
+func sessionKeyPairs(authKeys, encryptionKeys, legacy string) ([]KeyPair, error) {
+	auth, err := parseKeys(authKeys)
+	if err != nil {
+		return nil, fmt.Errorf("SESSION_AUTH_KEYS: %w", err)
+	}
+	enc, err := parseKeys(encryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("SESSION_ENCRYPTION_KEYS: %w", err)
+	}
+	if len(enc) > 0 && len(enc) != len(auth) {
+		return nil, fmt.Errorf("SESSION_ENCRYPTION_KEYS 的數量（%d）必須與 SESSION_AUTH_KEYS（%d）相同", len(enc), len(auth))
+	}
+
+	var pairs []KeyPair
+	for i, key := range auth {
+		kp := KeyPair{Auth: key}
+		if len(enc) > 0 {
+			kp.Encryption = enc[i]
+		}
+		pairs = append(pairs, kp)
+	}
+	if legacy != "" {
+		pairs = append(pairs, KeyPair{Auth: []byte(legacy)})
+	}
+	return pairs, nil
+}
+
+// GenerateKey 產生 n bytes 的隨機金鑰，以設定中可以使用的 base64: 格式返回
+func GenerateKey(n int) (string, error) {
+	key := make([]byte, n)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(key), nil
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 測試用的金鑰：strong 為隨機內容，weak 長度足夠但只有一種字元
+var (
+	strong32 = []byte("k3y-W1th.Many+Distinct/bytes_32!")
+	strong16 = []byte("Enc-Key.16/bytes")
+	weak32   = bytes.Repeat([]byte("a"), 32)
+	short    = []byte("short-secret")
+)
+
+func TestSessionConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyPairs []KeyPair
+		jwt      []byte
+		wantErr  []string // 錯誤訊息需要包含的內容，nil 表示通過
+	}{
+		{name: "no keys", jwt: strong32, wantErr: []string{"SESSION_AUTH_KEYS"}},
+		{name: "strong primary", keyPairs: []KeyPair{{Auth: strong32, Encryption: strong16}}, jwt: strong32},
+		{name: "primary without encryption", keyPairs: []KeyPair{{Auth: strong32}}, jwt: strong32},
+		{name: "short primary", keyPairs: []KeyPair{{Auth: short}}, jwt: strong32, wantErr: []string{"第 1 組驗證金鑰至少需要 32 bytes"}},
+		{name: "repeated primary", keyPairs: []KeyPair{{Auth: weak32}}, jwt: strong32, wantErr: []string{"第 1 組驗證金鑰太容易猜到"}},
+		{name: "weak primary encryption", keyPairs: []KeyPair{{Auth: strong32, Encryption: bytes.Repeat([]byte("b"), 16)}}, jwt: strong32, wantErr: []string{"第 1 組加密金鑰太容易猜到"}},
+		{name: "weak fallback allowed", keyPairs: []KeyPair{{Auth: strong32}, {Auth: short}}, jwt: strong32},
+		{name: "fallback encryption length", keyPairs: []KeyPair{{Auth: strong32}, {Auth: short, Encryption: []byte("15-byte-key-abc")}}, jwt: strong32, wantErr: []string{"第 2 組加密金鑰必須是 16、24 或 32 bytes"}},
+		{name: "short JWT secret", keyPairs: []KeyPair{{Auth: strong32}}, jwt: short, wantErr: []string{"JWT_SECRET"}},
+		{name: "all errors reported", keyPairs: []KeyPair{{Auth: short}}, jwt: short, wantErr: []string{"第 1 組驗證金鑰", "JWT_SECRET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SessionConfig{KeyPairs: tt.keyPairs, JWTSecret: tt.jwt}.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate = nil, want error mentioning %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionConfigWeakFallbackKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyPairs []KeyPair
+		want     []string
+	}{
+		{name: "primary only", keyPairs: []KeyPair{{Auth: short}}},
+		{name: "strong fallback", keyPairs: []KeyPair{{Auth: strong32}, {Auth: strong32, Encryption: strong16}}},
+		{name: "short fallback", keyPairs: []KeyPair{{Auth: strong32}, {Auth: short}}, want: []string{"第 2 組驗證金鑰至少需要 32 bytes"}},
+		{
+			name:     "weak fallback pairs",
+			keyPairs: []KeyPair{{Auth: strong32}, {Auth: weak32}, {Auth: strong32, Encryption: bytes.Repeat([]byte("c"), 16)}},
+			want:     []string{"第 2 組驗證金鑰太容易猜到", "第 3 組加密金鑰太容易猜到"},
+		},
+		// 長度錯誤的加密金鑰由 Validate 拒絕，不重複列出
+		{name: "invalid encryption length", keyPairs: []KeyPair{{Auth: strong32}, {Auth: strong32, Encryption: []byte("x")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := SessionConfig{KeyPairs: tt.keyPairs}.WeakFallbackKeys()
+			if len(errs) != len(tt.want) {
+				t.Fatalf("WeakFallbackKeys = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %v, want it to mention %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionKeyPairs(t *testing.T) {
+	newKey := "hex:" + strings.Repeat("01", 32)
+	oldKey := "base64:b2xkLWF1dGgta2V5"         // old-auth-key
+	encKey := "base64:RW5jLUtleS4xNi9ieXRlcw==" // Enc-Key.16/bytes
+
+	tests := []struct {
+		name      string
+		auth, enc string
+		legacy    string
+		wantAuth  []string
+		wantEnc   []string
+		wantErr   string
+	}{
+		{name: "legacy only", legacy: "legacy-secret", wantAuth: []string{"legacy-secret"}, wantEnc: []string{""}},
+		{name: "rotated keys with legacy", auth: newKey + "," + oldKey, legacy: "legacy-secret", wantAuth: []string{strings.Repeat("\x01", 32), "old-auth-key", "legacy-secret"}, wantEnc: []string{"", "", ""}},
+		{name: "paired encryption keys", auth: newKey + "," + oldKey, enc: encKey + "," + encKey, wantAuth: []string{strings.Repeat("\x01", 32), "old-auth-key"}, wantEnc: []string{"Enc-Key.16/bytes", "Enc-Key.16/bytes"}},
+		{name: "mismatched encryption keys", auth: newKey + "," + oldKey, enc: encKey, wantErr: "SESSION_ENCRYPTION_KEYS 的數量"},
+		{name: "invalid hex", auth: "hex:zz", wantErr: "SESSION_AUTH_KEYS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, err := sessionKeyPairs(tt.auth, tt.enc, tt.legacy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotAuth, gotEnc []string
+			for _, kp := range pairs {
+				gotAuth = append(gotAuth, string(kp.Auth))
+				gotEnc = append(gotEnc, string(kp.Encryption))
+			}
+			if !slices.Equal(gotAuth, tt.wantAuth) || !slices.Equal(gotEnc, tt.wantEnc) {
+				t.Errorf("pairs = %q / %q, want %q / %q", gotAuth, gotEnc, tt.wantAuth, tt.wantEnc)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"http-server/config"
+	"http-server/database"
+	"http-server/models"
+	"os"
+)
+
+const configUsage = `用法: http-server config <print|genkey>
+
+  print   列出所有設定的最終值與來源，金鑰與資料庫密碼會被遮蔽
+  genkey  產生隨機的 Session 與 JWT 金鑰，可以直接貼到 .env`
+
+// runConfig 執行 config 子命令，返回程序的結束碼
+func runConfig(cfg *config.Config, args []string) int {
+	if len(args) != 1 {
+		fmt.Println(configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "print":
+	case "genkey":
+		return runGenKey()
+	default:
+		fmt.Println(configUsage)
+		return 2
+	}
+
+	// 連線資料庫以顯示 config 表中的 runtime key，無法連線時只顯示其他來源
+	var db map[string]string
+	if !cfg.DemoMode && cfg.Database.DSN != "" {
+		// 只嘗試連線一次，資料庫無法連線時不等待
+		conn, err := database.InitDB(cfg.Database.DSN, database.Options{MaxOpenConns: 1})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "無法讀取 config 表: %v\n", err)
+		} else {
+			defer conn.Close()
+			entries, err := models.NewSQLConfigRepository(conn).GetAllConfigs()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "無法讀取 config 表: %v\n", err)
+			}
+			db = make(map[string]string, len(entries))
+			for _, entry := range entries {
+				db[entry.Key] = entry.Value
+			}
+		}
+	}
+
+	cfg.Print(os.Stdout, db)
+	return 0
+}
+
+// runGenKey 輸出一組新的金鑰，輪替時把新金鑰加在現有金鑰的最前面
+func runGenKey() int {
+	var keys [3]string
+	for i := range keys {
+		key, err := config.GenerateKey(32)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "產生金鑰失敗: %v\n", err)
+			return 1
+		}
+		keys[i] = key
+	}
+	fmt.Printf("SESSION_AUTH_KEYS=%s\n", keys[0])
+	fmt.Printf("SESSION_ENCRYPTION_KEYS=%s\n", keys[1])
+	fmt.Printf("JWT_SECRET=%s\n", keys[2])
+	return 0
+}
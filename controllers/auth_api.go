@@ -3,80 +3,82 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"http-server/config"
+	"http-server/logging"
+	"http-server/metrics"
 	"http-server/models"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type RegisterRequest struct {
-	Username  string `json:"username"`
-	Password  string `json:"password"`
-	Nickname  string `json:"nickname"`
-	Firstname string `json:"firstname"`
-	Lastname  string `json:"lastname"`
-	Email     string `json:"email"`
-	Gender    string `json:"gender"`
-	Birthday  string `json:"birthday"`
+	Username  string `json:"username" validate:"required,min=3,max=32,identifier"`
+	Password  string `json:"password" validate:"required"`
+	Nickname  string `json:"nickname" validate:"max=32"`
+	Firstname string `json:"firstname" validate:"max=50"`
+	Lastname  string `json:"lastname" validate:"max=50"`
+	Email     string `json:"email" validate:"omitempty,max=254,email"`
+	Gender    string `json:"gender" validate:"omitempty,oneof=male female other"`
+	Birthday  string `json:"birthday" validate:"omitempty,date,mindate=1900-01-01,past"`
 }
 
-func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// 檢查欄位與密碼規則，所有錯誤一次返回；密碼為空時已有 required 錯誤
+	fields := validateStruct(&req)
+	if req.Password != "" {
+		fields = append(fields, c.currentPasswordPolicy().Check(r.Context(), "password", req.Username, req.Password)...)
+	}
+	if !checkFields(w, fields) {
 		return
 	}
 
 	// 解析生日
 	var birthday *time.Time // 使用指針處理非必填情況
 	if req.Birthday != "" {
-		parsedBirthday, err := time.Parse("2006-01-02", req.Birthday)
-		if err != nil {
-			fmt.Printf("Birthday parsing error: %v\n", err)
-			http.Error(w, "Invalid birthday format. Use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
+		parsedBirthday, _ := time.Parse(dateLayout, req.Birthday) // 格式已在驗證時檢查
 		birthday = &parsedBirthday
 	}
 
-	// 驗證輸入
-	if req.Username == "" || req.Password == "" {
-		http.Error(w, "Username and Password are required", http.StatusBadRequest)
-		return
-	}
-
 	// 加密密碼
 	hashedPassword, err := HashPassword(req.Password)
 	if err != nil {
-		http.Error(w, "Failed to encrypt password", http.StatusInternalServerError)
-		return
-	}
-
-	// 新增用戶 Role給它一個預設值 87
-	err = models.AddUser(req.Username, string(hashedPassword), "87")
-	if err != nil {
-		if sql.ErrNoRows == err {
-			http.Error(w, "User already exists", http.StatusConflict)
-		} else {
-			http.Error(w, "Failed to create user", http.StatusInternalServerError)
-		}
+		logging.FromContext(r.Context()).Error("Hash password error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to encrypt password")
 		return
 	}
 
-	// 新增用戶資訊
-	err = models.AddProfile(req.Username, req.Nickname, req.Firstname, req.Lastname, req.Email, req.Gender, birthday)
+	// 在同一個交易中新增用戶（預設角色 87）與用戶資訊
+	err = c.Accounts.RegisterUser(models.Registration{
+		Username:     req.Username,
+		PasswordHash: string(hashedPassword),
+		RoleID:       models.DefaultRoleID,
+		Nickname:     req.Nickname,
+		Firstname:    req.Firstname,
+		Lastname:     req.Lastname,
+		Email:        req.Email,
+		Gender:       req.Gender,
+		Birthday:     birthday,
+	})
 	if err != nil {
-		if sql.ErrNoRows == err {
-			http.Error(w, "Profile already exists", http.StatusConflict)
+		if errors.Is(err, models.ErrDuplicate) {
+			writeError(w, http.StatusConflict, CodeUserExists, "User already exists")
 		} else {
-			http.Error(w, "Failed to create profile", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("Register error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to create user")
 		}
 		return
 	}
@@ -86,64 +88,113 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	IssueToken bool   `json:"issueToken"` // 為 true 時返回 JWT，供無法使用 Cookie 的客戶端使用
 }
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequestBody, "Invalid request body")
+		return
+	}
+
+	// 同一用戶名或 IP 失敗太多次時，暫時拒絕登入
+	limits := c.currentLoginLimits()
+	userKey := "user:" + strings.ToLower(req.Username)
+	ipKey := "ip:" + c.remoteIP(r)
+	if wait := c.guard.retryAfter(userKey, ipKey); wait > 0 {
+		c.Metrics.LoginAttempt(metrics.LoginLocked)
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, CodeTooManyAttempts, "Too many failed login attempts, please try again later")
 		return
 	}
 
 	// 查詢用戶
-	user, err := models.GetUserByUsername(req.Username)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "User not found", http.StatusUnauthorized)
-		} else {
-			http.Error(w, "User Database error", http.StatusInternalServerError)
-		}
+	user, err := c.Users.GetUserByUsername(req.Username)
+	if err != nil && err != sql.ErrNoRows {
+		c.Metrics.LoginAttempt(metrics.LoginError)
+		logging.FromContext(r.Context()).Error("User lookup error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeDatabaseError, "User Database error")
 		return
 	}
 
-	// 驗證密碼
-	if !CheckPasswordHash(req.Password, user.PasswordHash) {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	// 驗證密碼；用戶不存在時仍比對一次假的雜湊，讓回應時間一致
+	// 用戶不存在與密碼錯誤返回相同的錯誤，避免洩漏用戶名是否存在
+	var valid bool
+	if user != nil {
+		valid = CheckPasswordHash(req.Password, user.PasswordHash)
+	} else {
+		CheckPasswordHash(req.Password, dummyPasswordHash())
+	}
+	if !valid {
+		c.guard.recordFailure(userKey, limits.maxAttempts, limits)
+		// IP 只在達到上限時鎖定，不做退避，避免影響同一網路下的其他用戶
+		ipLimits := limits
+		ipLimits.backoffBase = 0
+		c.guard.recordFailure(ipKey, limits.ipMaxAttempts, ipLimits)
+		c.Metrics.LoginAttempt(metrics.LoginFailure)
+		writeError(w, http.StatusUnauthorized, CodeInvalidCredentials, "Invalid username or password")
 		return
 	}
+	c.guard.reset(userKey)
 
 	// 查詢用戶資訊
-	profile, err := models.GetProfileByUsername(user.Username)
+	profile, err := c.Profiles.GetProfileByUsername(user.Username)
 	if err != nil {
+		c.Metrics.LoginAttempt(metrics.LoginError)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Profile not found", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeProfileNotFound, "Profile not found")
 		} else {
-			http.Error(w, "Profile Database error", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("Profile lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Profile Database error")
 		}
 		return
 	}
 
 	// 查詢用戶角色
-	role, err := models.GetRoleById(user.RoleID)
+	role, err := c.Roles.GetRoleById(user.RoleID)
 	if err != nil {
+		c.Metrics.LoginAttempt(metrics.LoginError)
 		if err == sql.ErrNoRows {
-			http.Error(w, "Role not found", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeRoleNotFound, "Role not found")
 		} else {
-			http.Error(w, "Role Database error", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("Role lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Role Database error")
+		}
+		return
+	}
+
+	// 要求簽發 Token 時直接返回 Token，不建立 Session
+	if req.IssueToken {
+		tokens, err := c.issueTokens(user, role.ID)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Token sign error", "err", err)
+			c.Metrics.LoginAttempt(metrics.LoginError)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to issue token")
+			return
 		}
+		c.Metrics.LoginAttempt(metrics.LoginSuccess)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokens)
 		return
 	}
 
-	// 保存到 Session
-	session, _ := config.Store.Get(r, "session-name") // 創建/獲取 Session
-	session.Values["username"] = user.Username        // 保存用戶名到 Session
+	// 保存到 Session，登入前的 Session 作廢並改用新的 Session ID
+	session, _ := c.Sessions.Get(r, "session-name") // 創建/獲取 Session
+	if err := c.Sessions.Regenerate(session); err != nil {
+		logging.FromContext(r.Context()).Error("Session regenerate error", "err", err)
+		c.Metrics.LoginAttempt(metrics.LoginError)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to save session")
+		return
+	}
+	session.Values["username"] = user.Username // 保存用戶名到 Session
 	session.Values["id"] = user.ID
 	session.Values["nickname"] = profile.Nickname
 	session.Values["roleid"] = role.ID
@@ -151,18 +202,20 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	session.Values["gender"] = profile.Gender
 	seserr := session.Save(r, w) // 保存 Session
 	if seserr != nil {
-		fmt.Printf("Session save error: %v\n", seserr)
-		http.Error(w, "Failed to save session", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Session save error", "err", seserr)
+		c.Metrics.LoginAttempt(metrics.LoginError)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to save session")
 		return
 	}
 
 	// 返回成功響應
+	c.Metrics.LoginAttempt(metrics.LoginSuccess)
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintln(w, "Login successful")
 }
 
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	session, _ := config.Store.Get(r, "session-name")
+func (c *Controller) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	session, _ := c.Sessions.Get(r, "session-name")
 	session.Options.MaxAge = -1 // 設置過期時間，刪除 Session
 	session.Save(r, w)
 
@@ -170,54 +223,82 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // 定義 MeHandler，返回用戶 Session 資訊
-func MeHandler(w http.ResponseWriter, r *http.Request) {
-	// 從 Context 中取得用戶名
-	username, ok := r.Context().Value(UsernameContextKey).(string)
-	if !ok || username == "" {
-		http.Error(w, "未登入", http.StatusUnauthorized)
+func (c *Controller) MeHandler(w http.ResponseWriter, r *http.Request) {
+	// 從 Context 中取得用戶資訊
+	id, ok := identityFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
 		return
 	}
 
-	// 假設 Session 中保存了其他用戶資訊
-	session, _ := config.Store.Get(r, "session-name")
-	userID, _ := session.Values["id"].(int)
+	// Session 中保存了其他用戶資訊
+	session, _ := c.Sessions.Get(r, "session-name")
 	nickname, _ := session.Values["nickname"].(string)
-	roleid, _ := session.Values["roleid"].(int)
 	rolename, _ := session.Values["rolename"].(string)
 	gender, _ := session.Values["gender"].(string)
 
+	// 使用 Bearer Token 時沒有 Session，改從資料庫查詢
+	if sessionUser, _ := session.Values["username"].(string); sessionUser != id.Username {
+		profile, err := c.Profiles.GetProfileByUsername(id.Username)
+		if err != nil && err != sql.ErrNoRows {
+			logging.FromContext(r.Context()).Error("Profile lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Profile Database error")
+			return
+		}
+		if profile != nil {
+			nickname, gender = profile.Nickname, profile.Gender
+		}
+
+		role, err := c.Roles.GetRoleById(fmt.Sprintf("%d", id.RoleID))
+		if err != nil && err != sql.ErrNoRows {
+			logging.FromContext(r.Context()).Error("Role lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Role Database error")
+			return
+		}
+		if role != nil {
+			rolename = role.Name
+		}
+	}
+
 	// 返回用戶資訊
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
-		"id":       fmt.Sprintf("%d", userID),
-		"username": username,
+		"id":       fmt.Sprintf("%d", id.UserID),
+		"username": id.Username,
 		"nickname": nickname,
-		"roleid":   fmt.Sprintf("%d", roleid),
+		"roleid":   fmt.Sprintf("%d", id.RoleID),
 		"rolename": rolename,
 		"gender":   gender,
 	})
 }
 
-func ProfileHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) ProfileHandler(w http.ResponseWriter, r *http.Request) {
 	username := strings.TrimPrefix(r.URL.Path, "/auth/profile/")
 	if username == "" {
 		// 如果未提供 Username，返回 HTTP 400 錯誤
-		http.Error(w, "Missing Username", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeMissingParameter, "Missing Username", FieldError{Field: "username", Code: "required", Message: "Username is required"})
 		return
 	}
 
 	// 查詢用戶資訊
-	profile, err := models.GetProfileByUsername(username)
+	profile, err := c.Profiles.GetProfileByUsername(username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			http.Error(w, "Profile not found", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeProfileNotFound, "Profile not found")
 		} else {
-			http.Error(w, "Profile Database error", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("Profile lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Profile Database error")
 		}
 		return
 	}
 
+	// 生日為非必填，未填寫時返回空字串
+	var birthday string
+	if profile.Birthday != nil {
+		birthday = profile.Birthday.Format(dateLayout)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(struct {
 		Nickname  string `json:"nickname"`
@@ -232,68 +313,70 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 		Lastname:  profile.Lastname,
 		Email:     profile.Email,
 		Gender:    profile.Gender,
-		Birthday:  profile.Birthday.Format("2006-01-02"), // 格式化日期
+		Birthday:  birthday,
 	})
 }
 
 type UpdateProfileRequest struct {
-	Nickname  string `json:"nickname"`
-	Firstname string `json:"firstname"`
-	Lastname  string `json:"lastname"`
-	Email     string `json:"email"`
-	Gender    string `json:"gender"`
-	Birthday  string `json:"birthday"`
+	Nickname  string `json:"nickname" validate:"max=32"`
+	Firstname string `json:"firstname" validate:"max=50"`
+	Lastname  string `json:"lastname" validate:"max=50"`
+	Email     string `json:"email" validate:"omitempty,max=254,email"`
+	Gender    string `json:"gender" validate:"omitempty,oneof=male female other"`
+	Birthday  string `json:"birthday" validate:"omitempty,date,mindate=1900-01-01,past"`
 }
 
 // 修改資料
-func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 	// 確認請求方法是否為 PUT
 	if r.Method != http.MethodPut {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
 	username := strings.TrimPrefix(r.URL.Path, "/auth/profile/update/")
 	if username == "" {
 		// 如果未提供 Username，返回 HTTP 400 錯誤
-		http.Error(w, "Missing Username", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeMissingParameter, "Missing Username", FieldError{Field: "username", Code: "required", Message: "Username is required"})
+		return
+	}
+
+	// 只能修改自己的資料，管理員除外
+	if !c.authorizeOwner(w, r, username) {
 		return
 	}
 
 	var req UpdateProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
 
 	// 解析生日
 	var birthday *time.Time
 	if req.Birthday != "" {
-		parsedBirthday, err := time.Parse("2006-01-02", req.Birthday)
-		if err != nil {
-			fmt.Printf("Birthday parsing error: %v\n", err)
-			http.Error(w, "Invalid birthday format. Use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
+		parsedBirthday, _ := time.Parse(dateLayout, req.Birthday) // 格式已在驗證時檢查
 		birthday = &parsedBirthday
 	}
 
 	// 更新用戶資訊
-	if err := models.UpdateProfileByUsername(username, req.Nickname, req.Firstname, req.Lastname, req.Email, req.Gender, birthday); err != nil {
+	if err := c.Profiles.UpdateProfileByUsername(username, req.Nickname, req.Firstname, req.Lastname, req.Email, req.Gender, birthday); err != nil {
 		// 更新失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Update profile error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to update profile")
 		return
 	}
 
-	// 獲取當前 Session
-	session, _ := config.Store.Get(r, "session-name")
-	session.Values["nickname"] = req.Nickname
-	session.Values["gender"] = req.Gender
-	seserr := session.Save(r, w) // 保存 Session
-	if seserr != nil {
-		fmt.Printf("Session save error: %v\n", seserr)
-		http.Error(w, "Failed to save session", http.StatusInternalServerError)
-		return
+	// 修改的是自己的資料時，同步更新當前 Session
+	if current, ok := identityFromContext(r.Context()); ok && current.Username == username {
+		session, _ := c.Sessions.Get(r, "session-name")
+		session.Values["nickname"] = req.Nickname
+		session.Values["gender"] = req.Gender
+		seserr := session.Save(r, w) // 保存 Session
+		if seserr != nil {
+			logging.FromContext(r.Context()).Error("Session save error", "err", seserr)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to save session")
+			return
+		}
 	}
 
 	// 返回 HTTP 200 OK，表示更新成功
@@ -301,57 +384,110 @@ func UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 type ChangePasswordRequest struct {
-	OldPassword string `json:"oldPassword"`
-	NewPassword string `json:"newPassword"`
+	OldPassword string `json:"oldPassword" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+}
+
+// Validate 檢查新密碼不可與舊密碼相同
+func (req *ChangePasswordRequest) Validate() []FieldError {
+	if req.NewPassword != "" && req.NewPassword == req.OldPassword {
+		return []FieldError{{Field: "newPassword", Code: "unchanged", Message: "newPassword must differ from oldPassword"}}
+	}
+	return nil
 }
 
-func ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+func (c *Controller) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
 		return
 	}
 
 	username := strings.TrimPrefix(r.URL.Path, "/auth/change-password/")
 	if username == "" {
 		// 如果未提供 Username，返回 HTTP 400 錯誤
-		http.Error(w, "Missing Username", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeMissingParameter, "Missing Username", FieldError{Field: "username", Code: "required", Message: "Username is required"})
+		return
+	}
+
+	// 只能修改自己的資料，管理員除外
+	if !c.authorizeOwner(w, r, username) {
 		return
 	}
 
 	var req ChangePasswordRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
+	// 檢查欄位與新密碼規則，錯誤與下方的歷史密碼檢查一起返回
+	policy := c.currentPasswordPolicy()
+	fields := validateStruct(&req)
+	if req.NewPassword != "" {
+		fields = append(fields, policy.Check(r.Context(), "newPassword", username, req.NewPassword)...)
+	}
+
 	// 查詢用戶
-	user, err := models.GetUserByUsername(username)
+	user, err := c.Users.GetUserByUsername(username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			http.Error(w, "User not found", http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, CodeUserNotFound, "User not found")
 		} else {
-			http.Error(w, "User Database error", http.StatusInternalServerError)
+			logging.FromContext(r.Context()).Error("User lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "User Database error")
 		}
 		return
 	}
 
-	// 驗證密碼
-	if !CheckPasswordHash(req.OldPassword, user.PasswordHash) {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	// 驗證密碼；舊密碼為空時已有 required 錯誤
+	if req.OldPassword != "" && !CheckPasswordHash(req.OldPassword, user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, CodeInvalidCredentials, "Invalid credentials")
+		return
+	}
+
+	// 確認沒有重複使用最近的密碼；只在舊密碼驗證通過後比對，避免洩漏用過的密碼
+	if policy.History > 0 && req.OldPassword != "" && req.NewPassword != "" {
+		hashes, err := c.PasswordHistory.GetRecentPasswordHashes(user.ID, policy.History)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Password history error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Password history Database error")
+			return
+		}
+		fields = append(fields, policy.checkPasswordHistory("newPassword", req.NewPassword, hashes)...)
+	}
+	if !checkFields(w, fields) {
 		return
 	}
 
 	// 加密密碼
 	hashedPassword, err := HashPassword(req.NewPassword)
 	if err != nil {
-		http.Error(w, "Failed to encrypt password", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Hash password error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to encrypt password")
 		return
 	}
 
-	// 更新用戶密碼
-	if err := models.ChangePasswordByUsername(username, hashedPassword); err != nil {
+	// 更新用戶密碼，同時增加 token_version，使之前簽發的 Access Token 與 Refresh Token 全部失效
+	if err := c.Users.ChangePasswordByUsername(username, hashedPassword); err != nil {
 		// 更新失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to change password", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Change password error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to change password")
+		return
+	}
+
+	// 記錄舊密碼，之後不可再使用
+	if err := c.PasswordHistory.AddPasswordHistory(user.ID, user.PasswordHash); err != nil {
+		logging.FromContext(r.Context()).Error("Password history error", "err", err)
+	}
+
+	// 清除已失效的 Refresh Token
+	if err := c.RefreshTokens.DeleteRefreshTokensByUser(user.ID); err != nil {
+		logging.FromContext(r.Context()).Error("Revoke refresh token error", "err", err)
+	}
+
+	// 撤銷該用戶其他所有的 Session，保留發出此請求的 Session
+	if err := c.Sessions.RevokeAll(username, c.currentSessionID(r, username)); err != nil {
+		logging.FromContext(r.Context()).Error("Revoke session error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to revoke sessions")
 		return
 	}
 
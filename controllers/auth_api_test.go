@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestLoginRegeneratesSession(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "bob", testPassword, "87")
+	alice := login(t, c, "alice", testPassword)
+
+	// 帶著 alice 的 Cookie 以 bob 登入
+	req := newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: "bob", Password: testPassword})
+	req.AddCookie(alice)
+	rec := serve(http.HandlerFunc(c.LoginHandler), req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d, body %s", rec.Code, rec.Body)
+	}
+	var bob *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session-name" {
+			bob = cookie
+		}
+	}
+	if bob == nil {
+		t.Fatal("no session cookie")
+	}
+
+	// 登入前的 Cookie 失效，不會變成 bob 的身分
+	if isLoggedIn(c, alice) {
+		t.Error("cookie from before login is still logged in")
+	}
+	me := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	me.AddCookie(bob)
+	if rec := serve(c.Authenticate(http.HandlerFunc(c.MeHandler)), me); rec.Code != http.StatusOK {
+		t.Errorf("new cookie: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestChangePasswordRevokesTokens(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	tokens := loginToken(t, c, "alice", testPassword)
+
+	req := newJSONRequest(t, http.MethodPut, "/auth/change-password/alice", ChangePasswordRequest{OldPassword: testPassword, NewPassword: testNewPassword})
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	h := c.Authenticate(c.RequirePermission(PermProfileWrite)(http.HandlerFunc(c.ChangePasswordHandler)))
+	if rec := serve(h, req); rec.Code != http.StatusOK {
+		t.Fatalf("change password: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	me := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	me.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	if rec := serve(c.RequirePermission(PermProfileWrite)(http.HandlerFunc(c.MeHandler)), me); rec.Code != http.StatusUnauthorized {
+		t.Errorf("old access token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	refresh := newJSONRequest(t, http.MethodPost, "/auth/token/refresh", RefreshTokenRequest{RefreshToken: tokens.RefreshToken})
+	if rec := serve(http.HandlerFunc(c.RefreshTokenHandler), refresh); rec.Code != http.StatusUnauthorized {
+		t.Errorf("old refresh token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRegisterReportsAllFieldErrors(t *testing.T) {
+	c := newTestController(t, nil)
+
+	req := newJSONRequest(t, http.MethodPost, "/auth/register", RegisterRequest{Username: "alice", Password: "short", Email: "not-an-email"})
+	rec := serve(http.HandlerFunc(c.RegisterHandler), req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+
+	// 欄位驗證與密碼規則的錯誤在同一個響應中返回
+	fields := map[string]bool{}
+	for _, f := range decodeAPIError(t, rec).Errors {
+		fields[f.Field] = true
+	}
+	if !fields["email"] || !fields["password"] {
+		t.Errorf("field errors = %v, want email and password", fields)
+	}
+}
+
+func TestProfileWithoutBirthday(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+
+	rec := serve(http.HandlerFunc(c.ProfileHandler), httptest.NewRequest(http.MethodGet, "/auth/profile/alice", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var profile map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&profile); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if profile["birthday"] != "" {
+		t.Errorf("birthday = %q, want empty", profile["birthday"])
+	}
+}
+
+// changePassword 以 Session 登入的身分修改密碼
+func changePassword(t *testing.T, c *Controller, cookie *http.Cookie, username, oldPassword, newPassword string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := c.Authenticate(c.RequirePermission(PermProfileWrite)(http.HandlerFunc(c.ChangePasswordHandler)))
+	req := newJSONRequest(t, http.MethodPut, "/auth/change-password/"+username, ChangePasswordRequest{OldPassword: oldPassword, NewPassword: newPassword})
+	req.AddCookie(cookie)
+	return serve(h, req)
+}
+
+func TestChangePasswordRejectsReusedPassword(t *testing.T) {
+	c := newTestController(t, map[string]string{"password.history": "5"})
+	createUser(t, c, "alice", testPassword, "87")
+	cookie := login(t, c, "alice", testPassword)
+
+	if rec := changePassword(t, c, cookie, "alice", testPassword, testNewPassword); rec.Code != http.StatusOK {
+		t.Fatalf("first change: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	// 改回最近使用過的密碼
+	rec := changePassword(t, c, cookie, "alice", testNewPassword, testPassword)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("reuse: status = %d, want %d; body %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if got := fieldCodes(decodeAPIError(t, rec)); !slices.Equal(got, []string{"newPassword:reused"}) {
+		t.Errorf("field errors = %v, want [newPassword:reused]", got)
+	}
+
+	// 密碼沒有被修改
+	login(t, c, "alice", testNewPassword)
+}
@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"context"
-	"http-server/config"
+	"database/sql"
+	"errors"
+	"http-server/logging"
 	"net/http"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -12,24 +15,113 @@ import (
 type contextKey string
 
 // 定義特定的鍵
-const UsernameContextKey contextKey = "username"
+const (
+	UsernameContextKey contextKey = "username"
+	UserIDContextKey   contextKey = "userid"
+	RoleIDContextKey   contextKey = "roleid"
+)
+
+// errInvalidToken 表示請求帶了 Bearer Token，但 Token 無效或已過期
+var errInvalidToken = errors.New("invalid bearer token")
+
+// identity 是已登入用戶的基本資訊，來源可以是 Session 或 Bearer Token
+type identity struct {
+	UserID   int
+	Username string
+	RoleID   int
+}
 
-func Authenticate(next http.Handler) http.Handler {
+func (c *Controller) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, _ := config.Store.Get(r, "session-name") // 獲取 Session
-		username, ok := session.Values["username"].(string)
-		if !ok || username == "" {
+		id, err := c.identify(r)
+		if err != nil {
+			writeIdentifyError(w, r, err)
+			return
+		}
+		if id == nil {
 			// 未登入，重定向到登入頁面
 			http.Redirect(w, r, "/login", http.StatusSeeOther)
 			return
 		}
 
-		// 將用戶名存入 Context，供後續處理使用
-		ctx := context.WithValue(r.Context(), UsernameContextKey, username)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		// 將用戶資訊存入 Context，供後續處理使用
+		next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), id)))
 	})
 }
 
+// identify 從請求中解析當前用戶
+// 有 Authorization: Bearer 標頭時使用 Access Token，否則使用 Session；都沒有時返回 nil
+// Access Token 無效或已被撤銷時返回 errInvalidToken，查詢用戶失敗時返回資料庫的錯誤
+func (c *Controller) identify(r *http.Request) (*identity, error) {
+	if tokenString, ok := bearerToken(r); ok {
+		claims, err := c.parseToken(tokenString, accessTokenType)
+		if err != nil {
+			return nil, errInvalidToken
+		}
+
+		// 用戶已刪除或 token_version 已增加（例如修改密碼）時，Token 不再有效
+		user, err := c.Users.GetUserByUsername(claims.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errInvalidToken
+			}
+			return nil, err
+		}
+		if user.ID != claims.UserID || user.TokenVersion != claims.Version {
+			return nil, errInvalidToken
+		}
+		return &identity{UserID: claims.UserID, Username: claims.Username, RoleID: claims.RoleID}, nil
+	}
+
+	session, _ := c.Sessions.Get(r, "session-name") // 獲取 Session
+	username, ok := session.Values["username"].(string)
+	if !ok || username == "" {
+		return nil, nil
+	}
+	userID, _ := session.Values["id"].(int)
+	roleID, _ := session.Values["roleid"].(int)
+	return &identity{UserID: userID, Username: username, RoleID: roleID}, nil
+}
+
+// writeIdentifyError 寫入 identify 失敗時的錯誤響應
+func writeIdentifyError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errInvalidToken) {
+		writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Invalid or expired token")
+		return
+	}
+	logging.FromContext(r.Context()).Error("User lookup error", "err", err)
+	writeError(w, http.StatusInternalServerError, CodeDatabaseError, "User Database error")
+}
+
+// identityFromContext 取出 Authenticate 存入 Context 的用戶資訊
+func identityFromContext(ctx context.Context) (*identity, bool) {
+	username, ok := ctx.Value(UsernameContextKey).(string)
+	if !ok || username == "" {
+		return nil, false
+	}
+	userID, _ := ctx.Value(UserIDContextKey).(int)
+	roleID, _ := ctx.Value(RoleIDContextKey).(int)
+	return &identity{UserID: userID, Username: username, RoleID: roleID}, true
+}
+
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	ctx = context.WithValue(ctx, UsernameContextKey, id.Username)
+	ctx = context.WithValue(ctx, UserIDContextKey, id.UserID)
+	ctx = context.WithValue(ctx, RoleIDContextKey, id.RoleID)
+	return ctx
+}
+
+// bearerToken 取出 Authorization 標頭中的 Bearer Token
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func HashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(hash), err
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"database/sql"
+	"http-server/logging"
+	"net/http"
+	"strconv"
+)
+
+// 權限名稱，對應 role_permissions 表中的 permission 欄位
+const (
+	PermItemsRead    = "items:read"
+	PermItemsWrite   = "items:write"
+	PermProfileWrite = "profile:write"
+	PermAdmin        = "admin"
+)
+
+// RequireRole 只允許角色名稱在 roles 之內的用戶通過
+func (c *Controller) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, ok := c.requireIdentity(w, r)
+			if !ok {
+				return
+			}
+
+			id, _ := identityFromContext(r.Context())
+			role, err := c.Roles.GetRoleById(strconv.Itoa(id.RoleID))
+			if err != nil && err != sql.ErrNoRows {
+				logging.FromContext(r.Context()).Error("Role lookup error", "err", err)
+				writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Role Database error")
+				return
+			}
+			if role != nil {
+				for _, name := range roles {
+					if name == role.Name {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			writeError(w, http.StatusForbidden, CodeForbidden, "Forbidden")
+		})
+	}
+}
+
+// RequirePermission 只允許角色擁有指定權限的用戶通過
+// 角色的權限透過 models.GetRoleById 從資料庫讀取，因此權限調整後不需要重新登入
+func (c *Controller) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, ok := c.requireIdentity(w, r)
+			if !ok {
+				return
+			}
+
+			allowed, err := c.hasPermission(r, permission)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("Role lookup error", "err", err)
+				writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Role Database error")
+				return
+			}
+			if !allowed {
+				writeError(w, http.StatusForbidden, CodeForbidden, "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireIdentity 確保請求的 Context 中帶有用戶資訊
+// 若尚未經過 Authenticate，會自行從 Session 或 Bearer Token 解析；未登入時寫入 401 並返回 false
+func (c *Controller) requireIdentity(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if _, ok := identityFromContext(r.Context()); ok {
+		return r, true
+	}
+
+	id, err := c.identify(r)
+	if err != nil {
+		writeIdentifyError(w, r, err)
+		return r, false
+	}
+	if id == nil {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
+		return r, false
+	}
+	return r.WithContext(withIdentity(r.Context(), id)), true
+}
+
+// authorizeOwner 確認當前登入用戶可以操作 username 的資源：
+// 必須是本人，或是擁有 admin 權限。不允許時會直接寫入錯誤響應並返回 false。
+func (c *Controller) authorizeOwner(w http.ResponseWriter, r *http.Request, username string) bool {
+	current, ok := identityFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
+		return false
+	}
+	if current.Username == username {
+		return true
+	}
+
+	allowed, err := c.hasPermission(r, PermAdmin)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Role lookup error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Role Database error")
+		return false
+	}
+	if !allowed {
+		writeError(w, http.StatusForbidden, CodeForbidden, "Forbidden")
+		return false
+	}
+	return true
+}
+
+// hasPermission 判斷 Context 中用戶的角色是否擁有指定權限
+func (c *Controller) hasPermission(r *http.Request, permission string) (bool, error) {
+	id, ok := identityFromContext(r.Context())
+	if !ok {
+		return false, nil
+	}
+
+	role, err := c.Roles.GetRoleById(strconv.Itoa(id.RoleID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return role.HasPermission(permission), nil
+}
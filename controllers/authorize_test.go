@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequirePermission(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "rita", testPassword, "2")
+	createUser(t, c, "root", testPassword, "1")
+	createUser(t, c, "gus", testPassword, "3")
+
+	tests := []struct {
+		name       string
+		permission string
+		username   string
+		token      string // 不為空時直接使用，不登入
+		want       int
+		wantCode   string
+	}{
+		{name: "anonymous", permission: PermItemsRead, want: http.StatusUnauthorized, wantCode: CodeUnauthenticated},
+		{name: "invalid token", permission: PermItemsRead, token: "not-a-jwt", want: http.StatusUnauthorized, wantCode: CodeInvalidToken},
+		{name: "read without permission", permission: PermItemsRead, username: "gus", want: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "read", permission: PermItemsRead, username: "rita", want: http.StatusNoContent},
+		{name: "write without permission", permission: PermItemsWrite, username: "rita", want: http.StatusForbidden, wantCode: CodeForbidden},
+		{name: "write", permission: PermItemsWrite, username: "alice", want: http.StatusNoContent},
+		{name: "admin", permission: PermItemsWrite, username: "root", want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := c.RequirePermission(tt.permission)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+			token := tt.token
+			if tt.username != "" {
+				token = loginToken(t, c, tt.username, testPassword).AccessToken
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.wantCode != "" {
+				if got := decodeAPIError(t, rec).Code; got != tt.wantCode {
+					t.Errorf("code = %q, want %q", got, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthorizeOwner(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "bob", testPassword, "87")
+	createUser(t, c, "root", testPassword, "1")
+
+	// 與 routes 相同：需要登入且擁有 profile:write 權限
+	h := c.Authenticate(c.RequirePermission(PermProfileWrite)(http.HandlerFunc(c.UpdateProfileHandler)))
+
+	tests := []struct {
+		name     string
+		username string
+		target   string
+		want     int
+	}{
+		{name: "own profile", username: "alice", target: "alice", want: http.StatusOK},
+		{name: "other user's profile", username: "alice", target: "bob", want: http.StatusForbidden},
+		{name: "admin", username: "root", target: "bob", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newJSONRequest(t, http.MethodPut, "/auth/profile/update/"+tt.target, UpdateProfileRequest{Nickname: "nick"})
+			req.AddCookie(login(t, c, tt.username, testPassword))
+
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusForbidden {
+				if got := decodeAPIError(t, rec).Code; got != CodeForbidden {
+					t.Errorf("code = %q, want %q", got, CodeForbidden)
+				}
+				profile, _ := c.Profiles.GetProfileByUsername(tt.target)
+				if profile.Nickname != "" {
+					t.Errorf("nickname of %s changed to %q", tt.target, profile.Nickname)
+				}
+			}
+		})
+	}
+}
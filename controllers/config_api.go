@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-server/config"
+	"http-server/logging"
+	"http-server/models"
+	"net/http"
+	"strconv"
+)
+
+// ConfigResponse 是返回給管理員的配置項目，Type 與 Description 來自已知 key 的登記
+type ConfigResponse struct {
+	Key         string         `json:"key"`
+	Value       string         `json:"value"`
+	Type        config.KeyType `json:"type,omitempty"`
+	Description string         `json:"description,omitempty"`
+}
+
+// ConfigListResponse 是所有配置與可設定的 key
+type ConfigListResponse struct {
+	Configs   []ConfigResponse       `json:"configs"`
+	KnownKeys []config.KeyDefinition `json:"known_keys"`
+}
+
+// CreateConfigRequest 是新增配置的請求
+type CreateConfigRequest struct {
+	Key   string `json:"key" validate:"required,max=100"`
+	Value string `json:"value"`
+}
+
+// Validate 檢查 key 是否已登記，以及 value 是否符合 key 的類型
+func (req CreateConfigRequest) Validate() []FieldError {
+	if req.Key == "" {
+		return nil
+	}
+	return validateConfigValue(req.Key, req.Value)
+}
+
+// UpdateConfigRequest 是修改配置的請求
+type UpdateConfigRequest struct {
+	Value string `json:"value"`
+}
+
+// 修改紀錄的預設與最大筆數
+const (
+	defaultConfigAuditLimit = 50
+	maxConfigAuditLimit     = 200
+)
+
+func newConfigResponse(entry models.ConfigEntry) ConfigResponse {
+	resp := ConfigResponse{Key: entry.Key, Value: entry.Value}
+	if def, ok := config.LookupKey(entry.Key); ok {
+		resp.Type = def.Type
+		resp.Description = def.Description
+	}
+	return resp
+}
+
+// validateConfigValue 依照已登記的 key 定義驗證 value
+func validateConfigValue(key, value string) []FieldError {
+	def, ok := config.LookupKey(key)
+	if !ok {
+		return []FieldError{{Field: "key", Code: "unknown_key", Message: fmt.Sprintf("%s is not a known config key", key)}}
+	}
+	if err := def.Validate(value); err != nil {
+		return []FieldError{{Field: "value", Code: "invalid_type", Message: err.Error()}}
+	}
+	return nil
+}
+
+// reloadConfig 在寫入 config 表後重新載入 ConfigManager，讓修改立即生效
+func (c *Controller) reloadConfig(r *http.Request) {
+	if err := c.Config.Reload(); err != nil {
+		logging.FromContext(r.Context()).Error("Config reload error", "err", err)
+	}
+}
+
+// changedBy 返回當前登入的用戶名，用於修改紀錄
+func changedBy(r *http.Request) string {
+	id, _ := identityFromContext(r.Context())
+	if id == nil {
+		return ""
+	}
+	return id.Username
+}
+
+// 查詢所有配置
+func (c *Controller) ListConfigsHandler(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.Configs.GetAllConfigs()
+	if err != nil {
+		logging.FromContext(r.Context()).Error("List config error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		return
+	}
+
+	resp := ConfigListResponse{Configs: make([]ConfigResponse, 0, len(entries)), KnownKeys: config.KnownKeys()}
+	for _, entry := range entries {
+		resp.Configs = append(resp.Configs, newConfigResponse(entry))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 查詢單一配置
+func (c *Controller) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := c.Configs.GetConfig(r.PathValue("key"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeConfigNotFound, "Config not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Get config error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newConfigResponse(*entry))
+}
+
+// 新增配置
+func (c *Controller) CreateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateConfigRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if err := c.Configs.AddConfig(req.Key, req.Value, changedBy(r)); err != nil {
+		if errors.Is(err, models.ErrDuplicate) {
+			writeError(w, http.StatusConflict, CodeConfigExists, "Config already exists")
+		} else {
+			logging.FromContext(r.Context()).Error("Add config error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		}
+		return
+	}
+	c.reloadConfig(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/admin/config/"+req.Key)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newConfigResponse(models.ConfigEntry{Key: req.Key, Value: req.Value}))
+}
+
+// 修改配置
+func (c *Controller) UpdateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var req UpdateConfigRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if fields := validateConfigValue(key, req.Value); len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, CodeValidationFailed, "Request validation failed", fields...)
+		return
+	}
+
+	if err := c.Configs.UpdateConfig(key, req.Value, changedBy(r)); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeConfigNotFound, "Config not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Update config error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		}
+		return
+	}
+	c.reloadConfig(r)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newConfigResponse(models.ConfigEntry{Key: key, Value: req.Value}))
+}
+
+// 刪除配置，刪除後使用程式中的預設值
+func (c *Controller) DeleteConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if err := c.Configs.DeleteConfig(r.PathValue("key"), changedBy(r)); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeConfigNotFound, "Config not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Delete config error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		}
+		return
+	}
+	c.reloadConfig(r)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 查詢配置的修改紀錄，支援 key（只查詢某個 key）與 limit
+func (c *Controller) ListConfigAuditHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit := defaultConfigAuditLimit
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxConfigAuditLimit {
+			detail := fmt.Sprintf("Invalid limit, must be between 1 and %d", maxConfigAuditLimit)
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, detail, FieldError{Field: "limit", Code: "out_of_range", Message: detail})
+			return
+		}
+		limit = n
+	}
+
+	audits, err := c.Configs.GetConfigAudit(params.Get("key"), limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("List config audit error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Config Database error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audits)
+}
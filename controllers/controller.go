@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"http-server/app"
+)
+
+// Controller 提供所有 HTTP 處理函數與中介層，依賴透過內嵌的 App 取得
+type Controller struct {
+	*app.App
+
+	guard     *loginGuard        // 登入失敗紀錄
+	blocklist *passwordBlocklist // 已載入的常見密碼清單
+}
+
+// New 建立使用 a 的 Controller
+func New(a *app.App) *Controller {
+	return &Controller{
+		App:       a,
+		guard:     &loginGuard{attempts: make(map[string]*loginAttempts)},
+		blocklist: &passwordBlocklist{},
+	}
+}
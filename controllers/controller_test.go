@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"http-server/app"
+	"http-server/config"
+	"http-server/metrics"
+	"http-server/models"
+	"http-server/sessionstore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 測試用的密碼，符合預設的密碼規則
+const (
+	testPassword    = "correct-horse-battery"
+	testNewPassword = "staple-orbit-lantern"
+)
+
+// testRoles 是測試中使用的角色：管理員、一般用戶（預設角色）、只能讀取 items 的用戶與沒有任何權限的用戶
+var testRoles = []models.Role{
+	{ID: 1, Name: "admin", Permissions: []string{PermAdmin, PermItemsRead, PermItemsWrite, PermProfileWrite}},
+	{ID: 2, Name: "reader", Permissions: []string{PermItemsRead}},
+	{ID: 3, Name: "guest"},
+	{ID: 87, Name: "user", Permissions: []string{PermItemsRead, PermItemsWrite, PermProfileWrite}},
+}
+
+// newTestController 建立使用記憶體 Repository 與 Session Store 的 Controller
+// runtime 為 config 表中的配置，例如 login.max_attempts
+func newTestController(t *testing.T, runtime map[string]string) *Controller {
+	t.Helper()
+
+	defaults := map[string]string{"password.blocklist_file": "../data/common-passwords.txt"}
+	for key, value := range runtime {
+		defaults[key] = value
+	}
+	configs := models.NewMemoryConfigRepository(nil)
+	manager, err := config.NewConfigManager(configs, defaults)
+	if err != nil {
+		t.Fatalf("NewConfigManager: %v", err)
+	}
+
+	sessions := sessionstore.NewMemoryStore(bytes.Repeat([]byte("0123456789abcdef"), 2), []byte("fedcba9876543210"))
+	t.Cleanup(func() { sessions.Close() })
+
+	users := models.NewMemoryUserRepository()
+	profiles := models.NewMemoryProfileRepository(users)
+	return New(&app.App{
+		Sessions:        sessions,
+		Config:          manager,
+		JWTSecret:       []byte("test-jwt-secret-0123456789abcdef"),
+		Metrics:         metrics.New(nil),
+		Users:           users,
+		Profiles:        profiles,
+		Roles:           models.NewMemoryRoleRepository(testRoles...),
+		Items:           models.NewMemoryItemRepository(),
+		Configs:         configs,
+		PasswordHistory: models.NewMemoryPasswordHistoryRepository(),
+		Accounts:        models.NewMemoryAccountRepository(users, profiles),
+		RefreshTokens:   models.NewMemoryRefreshTokenRepository(),
+	})
+}
+
+// createUser 直接透過 Repository 新增用戶與空白的用戶資訊
+func createUser(t *testing.T, c *Controller, username, password, roleID string) {
+	t.Helper()
+	hash, err := HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if err := c.Accounts.RegisterUser(models.Registration{Username: username, PasswordHash: hash, RoleID: roleID}); err != nil {
+		t.Fatalf("RegisterUser(%s): %v", username, err)
+	}
+}
+
+// newJSONRequest 建立以 body 的 JSON 作為請求體的請求
+func newJSONRequest(t *testing.T, method, target string, body any) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return httptest.NewRequest(method, target, bytes.NewReader(data))
+}
+
+// serve 執行處理函數並返回響應
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+// login 以 Session 登入，返回 Session Cookie
+func login(t *testing.T, c *Controller, username, password string) *http.Cookie {
+	t.Helper()
+	rec := serve(http.HandlerFunc(c.LoginHandler), newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: username, Password: password}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s: status %d, body %s", username, rec.Code, rec.Body)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session-name" {
+			return cookie
+		}
+	}
+	t.Fatalf("login %s: no session cookie", username)
+	return nil
+}
+
+// loginToken 登入並取得 JWT
+func loginToken(t *testing.T, c *Controller, username, password string) *TokenResponse {
+	t.Helper()
+	rec := serve(http.HandlerFunc(c.LoginHandler), newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: username, Password: password, IssueToken: true}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s: status %d, body %s", username, rec.Code, rec.Body)
+	}
+	var tokens TokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&tokens); err != nil {
+		t.Fatalf("decode tokens: %v", err)
+	}
+	return &tokens
+}
+
+// decodeAPIError 解析錯誤響應
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	var apiErr APIError
+	if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil {
+		t.Fatalf("decode error response: %v", err)
+	}
+	return apiErr
+}
+
+// fieldCodes 返回錯誤響應中每個欄位錯誤的 欄位:代碼
+func fieldCodes(apiErr APIError) []string {
+	codes := make([]string, 0, len(apiErr.Errors))
+	for _, f := range apiErr.Errors {
+		codes = append(codes, f.Field+":"+f.Code)
+	}
+	return codes
+}
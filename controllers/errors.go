@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// 錯誤代碼，提供前端判斷錯誤類型使用，一旦發布就不應修改
+const (
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeInvalidRequestBody = "invalid_request_body"
+	CodeValidationFailed   = "validation_failed"
+	CodeMissingParameter   = "missing_parameter"
+	CodeInvalidParameter   = "invalid_parameter"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeInvalidToken       = "invalid_token"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeForbidden          = "forbidden"
+	CodeTooManyAttempts    = "too_many_attempts"
+	CodeUserNotFound       = "user_not_found"
+	CodeProfileNotFound    = "profile_not_found"
+	CodeRoleNotFound       = "role_not_found"
+	CodeItemNotFound       = "item_not_found"
+	CodeSessionNotFound    = "session_not_found"
+	CodeConfigNotFound     = "config_not_found"
+	CodeUserExists         = "user_exists"
+	CodeConfigExists       = "config_exists"
+	CodeDatabaseError      = "database_error"
+	CodeInternalError      = "internal_error"
+)
+
+// APIError 是所有 API 的錯誤響應，格式遵循 RFC 7807（application/problem+json）
+// Code 是穩定的錯誤代碼，Detail 是給人看的說明，Errors 為欄位層級的錯誤
+type APIError struct {
+	Type   string       `json:"type"`
+	Title  string       `json:"title"`
+	Status int          `json:"status"`
+	Code   string       `json:"code"`
+	Detail string       `json:"detail,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError 描述單一欄位的錯誤
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Detail
+}
+
+// NewAPIError 建立錯誤響應，Title 使用 HTTP 狀態碼的標準說明
+func NewAPIError(status int, code, detail string, fields ...FieldError) *APIError {
+	return &APIError{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+		Errors: fields,
+	}
+}
+
+// writeError 以 application/problem+json 格式寫入錯誤響應
+func writeError(w http.ResponseWriter, status int, code, detail string, fields ...FieldError) {
+	writeAPIError(w, NewAPIError(status, code, detail, fields...))
+}
+
+func writeAPIError(w http.ResponseWriter, e *APIError) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(e)
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"http-server/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// readinessTimeout 是 /readyz 所有檢查的時間上限，超過時視為失敗
+const readinessTimeout = 2 * time.Second
+
+// 健康檢查的狀態
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// HealthCheck 是一項檢查的結果，Details 為額外資訊（例如連線池統計）
+// Error 與 Details 只返回給管理員
+type HealthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+}
+
+// HealthResponse 是 /healthz 與 /readyz 的響應，所有檢查都通過時 Status 為 ok
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// 存活檢查：行程可以處理請求即返回 200，不檢查任何依賴，避免資料庫故障時被重啟
+func (c *Controller) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	if !healthMethod(w, r) {
+		return
+	}
+	writeHealth(w, http.StatusOK, HealthResponse{Status: HealthOK})
+}
+
+// 就緒檢查：資料庫、配置與 Session Store 都可以使用時返回 200，否則返回 503
+// 每項檢查都返回狀態與耗時；錯誤訊息與連線池統計只有管理員可以查看，失敗的原因另外記錄在日誌中
+func (c *Controller) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	if !healthMethod(w, r) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]func(ctx context.Context) (any, error){
+		"config":        c.checkConfig,
+		"session_store": func(ctx context.Context) (any, error) { return nil, c.Sessions.Ping(ctx) },
+	}
+	// 展示模式沒有資料庫
+	if c.DB != nil {
+		checks["database"] = c.checkDatabase
+	}
+
+	resp := HealthResponse{Status: HealthOK, Checks: make(map[string]HealthCheck, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check(ctx)
+			latency := float64(time.Since(start).Microseconds()) / 1000
+			result := HealthCheck{Status: HealthOK, LatencyMS: latency, Details: details}
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Readiness check failed", "check", name, "err", err)
+				result.Status = HealthFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			resp.Checks[name] = result
+			if err != nil {
+				resp.Status = HealthFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !c.showHealthDetails(r) {
+		for name, check := range resp.Checks {
+			resp.Checks[name] = HealthCheck{Status: check.Status, LatencyMS: check.LatencyMS}
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Status != HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, resp)
+}
+
+// showHealthDetails 判斷請求者是否為管理員，可以查看檢查的詳細結果
+// /readyz 不經過 Authenticate，登入資訊無效時視為未登入
+// 探測通常不帶登入資訊，此時不查詢 Session 與用戶
+func (c *Controller) showHealthDetails(r *http.Request) bool {
+	if r.Header.Get("Authorization") == "" {
+		if _, err := r.Cookie("session-name"); err != nil {
+			return false
+		}
+	}
+	id, err := c.identify(r)
+	if err != nil || id == nil {
+		return false
+	}
+	allowed, err := c.hasPermission(r.WithContext(withIdentity(r.Context(), id)), PermAdmin)
+	return err == nil && allowed
+}
+
+// checkDatabase 在期限內 Ping 資料庫，並附上連線池統計
+func (c *Controller) checkDatabase(ctx context.Context) (any, error) {
+	stats, _ := c.DBStats()
+	return stats, c.DB.PingContext(ctx)
+}
+
+// checkConfig 確認配置已經從 config 表載入過
+func (c *Controller) checkConfig(context.Context) (any, error) {
+	if c.Config == nil || c.Config.LoadedAt().IsZero() {
+		return nil, errors.New("config not loaded")
+	}
+	return map[string]time.Time{"loaded_at": c.Config.LoadedAt()}, nil
+}
+
+// healthMethod 只接受 GET 與 HEAD，其他方法返回 405
+func healthMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return false
+	}
+	return true
+}
+
+// writeHealth 寫入健康檢查的結果，結果不可被快取
+func writeHealth(w http.ResponseWriter, status int, resp HealthResponse) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
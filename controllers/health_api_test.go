@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// readyz 執行就緒檢查，返回狀態碼與各項檢查的原始 JSON 欄位
+func readyz(t *testing.T, c *Controller, r *http.Request) (int, map[string]map[string]any) {
+	t.Helper()
+	rec := serve(http.HandlerFunc(c.ReadinessHandler), r)
+	var resp struct {
+		Checks map[string]map[string]any `json:"checks"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return rec.Code, resp.Checks
+}
+
+func TestReadinessDetails(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "root", testPassword, "1")
+
+	tests := []struct {
+		name        string
+		username    string
+		token       string
+		wantDetails bool
+	}{
+		{name: "anonymous probe"},
+		{name: "invalid token", token: "not-a-jwt"},
+		{name: "user", username: "alice"},
+		{name: "admin", username: "root", wantDetails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			token := tt.token
+			if tt.username != "" {
+				token = loginToken(t, c, tt.username, testPassword).AccessToken
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			status, checks := readyz(t, c, req)
+			if status != http.StatusOK {
+				t.Fatalf("status = %d, want %d", status, http.StatusOK)
+			}
+			for _, name := range []string{"config", "session_store"} {
+				check, ok := checks[name]
+				if !ok {
+					t.Fatalf("missing check %s", name)
+				}
+				if check["status"] != HealthOK {
+					t.Errorf("%s status = %v, want %s", name, check["status"], HealthOK)
+				}
+				// 耗時不是敏感資訊，所有人都可以看到
+				if _, ok := check["latency_ms"]; !ok {
+					t.Errorf("%s has no latency_ms", name)
+				}
+			}
+			if _, ok := checks["config"]["details"]; ok != tt.wantDetails {
+				t.Errorf("config details shown = %v, want %v", ok, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestReadinessFailureHidesError(t *testing.T) {
+	c := newTestController(t, nil)
+	c.Config = nil
+
+	status, checks := readyz(t, c, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+	if checks["config"]["status"] != HealthFail {
+		t.Errorf("config status = %v, want %s", checks["config"]["status"], HealthFail)
+	}
+	if _, ok := checks["config"]["error"]; ok {
+		t.Error("error message shown to anonymous probe")
+	}
+}
@@ -1,73 +1,189 @@
 package controllers
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"http-server/logging"
 	"http-server/models"
 	"net/http"
-	"strings"
+	"strconv"
 )
 
 // 新增資料
-func AddItemHandler(w http.ResponseWriter, r *http.Request) {
-	// 確認請求方法是否為 POST
-	if r.Method != http.MethodPost {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-		return
-	}
-
+func (c *Controller) AddItemHandler(w http.ResponseWriter, r *http.Request) {
 	// 解析請求體中的 JSON，並將其映射到 Item 結構
 	var item models.Item
-	err := json.NewDecoder(r.Body).Decode(&item)
-	if err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeAndValidate(w, r, &item) {
 		return
 	}
 
 	// 將資料插入到資料庫
-	if err := models.AddItem(item.Value); err != nil {
+	id, err := c.Items.AddItem(item.Value)
+	if err != nil {
 		// 插入資料失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to insert item", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Add item error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to insert item")
 		return
 	}
+	item.ID = id
 
-	// 返回 HTTP 201 Created，表示新增成功
+	// 返回 HTTP 201 Created 與新增的資料，表示新增成功
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/items/%d", id))
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// 查詢單筆資料
+func (c *Controller) GetItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemIDFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := c.Items.GetItemByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeItemNotFound, "Item not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Item lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to fetch item")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// 分頁的預設與最大筆數
+const (
+	defaultItemLimit = 20
+	maxItemLimit     = 100
+)
+
+// ItemListResponse 是查詢 items 的分頁結果
+type ItemListResponse struct {
+	Items      []models.Item `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"` // 沒有下一頁時省略
+	Total      int           `json:"total"`                 // 符合篩選條件的總筆數
+}
+
+// itemCursor 是游標的內容，編碼成 base64 後交給客戶端，Sort 用來確認游標與排序方式一致
+type itemCursor struct {
+	Sort  string `json:"s"`
+	ID    int    `json:"id"`
+	Value string `json:"v"`
 }
 
 // 查詢資料
-func GetItemsHandler(w http.ResponseWriter, r *http.Request) {
-	items, err := models.GetAllItems()
+// 支援 limit、cursor 或 page、sort（id、-id、value、-value）、q（value 子字串篩選）
+func (c *Controller) GetItemsHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := models.ItemQuery{
+		Limit:  defaultItemLimit,
+		Sort:   "id",
+		Search: params.Get("q"),
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxItemLimit {
+			detail := fmt.Sprintf("Invalid limit, must be between 1 and %d", maxItemLimit)
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, detail, FieldError{Field: "limit", Code: "out_of_range", Message: detail})
+			return
+		}
+		query.Limit = limit
+	}
+
+	if v := params.Get("sort"); v != "" {
+		if !models.ValidItemSort(v) {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "Invalid sort", FieldError{Field: "sort", Code: "invalid", Message: "Must be one of id, -id, value, -value"})
+			return
+		}
+		query.Sort = v
+	}
+
+	cursor, page := params.Get("cursor"), params.Get("page")
+	if cursor != "" && page != "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, "Use either cursor or page, not both")
+		return
+	}
+	if cursor != "" {
+		after, err := decodeItemCursor(cursor, query.Sort)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "Invalid cursor", FieldError{Field: "cursor", Code: "invalid", Message: "Cursor is malformed or does not match sort"})
+			return
+		}
+		query.After = after
+	}
+	if page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidParameter, "Invalid page", FieldError{Field: "page", Code: "invalid", Message: "Must be a positive integer"})
+			return
+		}
+		query.Offset = (n - 1) * query.Limit
+	}
+
+	items, total, hasMore, err := c.Items.QueryItems(query)
 	if err != nil {
 		// 查詢失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to fetch items", http.StatusInternalServerError)
+		logging.FromContext(r.Context()).Error("Query items error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to fetch items")
 		return
 	}
 
-	// 將 items 列表以 JSON 格式返回給用戶
+	resp := ItemListResponse{Items: items, Total: total}
+	if hasMore {
+		last := items[len(items)-1]
+		resp.NextCursor = encodeItemCursor(itemCursor{Sort: query.Sort, ID: last.ID, Value: last.Value})
+	}
+
+	// 將分頁結果以 JSON 格式返回給用戶
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(items)
+	json.NewEncoder(w).Encode(resp)
 }
 
-// 刪除資料
-func DeleteItemHandler(w http.ResponseWriter, r *http.Request) {
-	// 確認請求方法是否為 DELETE
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-		return
+func encodeItemCursor(c itemCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeItemCursor 解析游標，游標的排序方式必須與本次查詢相同
+func decodeItemCursor(s, sort string) (*models.ItemCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c itemCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
 	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("cursor sort %q does not match %q", c.Sort, sort)
+	}
+	return &models.ItemCursor{ID: c.ID, Value: c.Value}, nil
+}
 
-	// 從 URL 中提取 ID，例如 /api/items/delete/1 中提取到 "1"
-	id := strings.TrimPrefix(r.URL.Path, "/api/items/delete/")
-	if id == "" {
-		// 如果未提供 ID，返回 HTTP 400 錯誤
-		http.Error(w, "Missing item ID", http.StatusBadRequest)
+// 刪除資料
+func (c *Controller) DeleteItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
 	// 執行刪除操作
-	if err := models.DeleteItem(id); err != nil {
-		// 刪除失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to delete item", http.StatusInternalServerError)
+	if err := c.Items.DeleteItem(id); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeItemNotFound, "Item not found")
+		} else {
+			// 刪除失敗，返回 HTTP 500 錯誤
+			logging.FromContext(r.Context()).Error("Delete item error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to delete item")
+		}
 		return
 	}
 
@@ -75,40 +191,85 @@ func DeleteItemHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// 修改資料
-func UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
-	// 確認請求方法是否為 PUT
-	if r.Method != http.MethodPut {
-		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+// 修改資料（PUT 為整筆取代，value 必填）
+func (c *Controller) UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
-	// 從 URL 中提取 ID，例如 /api/items/update/1 中提取到 "1"
-	id := strings.TrimPrefix(r.URL.Path, "/api/items/update/")
-	if id == "" {
-		// 如果未提供 ID，返回 HTTP 400 錯誤
-		http.Error(w, "Missing item ID", http.StatusBadRequest)
+	// 解析請求體中的 JSON，並將其映射到 Item 結構
+	var item models.Item
+	if !decodeAndValidate(w, r, &item) {
 		return
 	}
 
-	// 解析請求體中的 JSON，並將其映射到 Item 結構
-	type Item struct {
-		Value string `json:"value"`
+	// 更新資料庫中的資料
+	if err := c.Items.UpdateItem(id, item.Value); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeItemNotFound, "Item not found")
+		} else {
+			// 更新失敗，返回 HTTP 500 錯誤
+			logging.FromContext(r.Context()).Error("Update item error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to update item")
+		}
+		return
 	}
-	var item Item
-	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		// 請求體格式錯誤，返回 HTTP 400 錯誤
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	item.ID = id
+
+	// 返回 HTTP 200 OK 與更新後的資料，表示更新成功
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// 部分修改資料（PATCH 只更新有提供的欄位）
+func (c *Controller) PatchItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemIDFromPath(w, r)
+	if !ok {
 		return
 	}
 
-	// 更新資料庫中的資料
-	if err := models.UpdateItem(id, item.Value); err != nil {
-		// 更新失敗，返回 HTTP 500 錯誤
-		http.Error(w, "Failed to update item", http.StatusInternalServerError)
+	var patch struct {
+		Value *string `json:"value" validate:"required,max=255"`
+	}
+	if !decodeAndValidate(w, r, &patch) {
+		return
+	}
+
+	item, err := c.Items.GetItemByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, CodeItemNotFound, "Item not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Item lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to fetch item")
+		}
 		return
 	}
 
-	// 返回 HTTP 200 OK，表示更新成功
-	w.WriteHeader(http.StatusOK)
+	if patch.Value != nil {
+		item.Value = *patch.Value
+		if err := c.Items.UpdateItem(id, item.Value); err != nil {
+			if err == sql.ErrNoRows {
+				writeError(w, http.StatusNotFound, CodeItemNotFound, "Item not found")
+			} else {
+				logging.FromContext(r.Context()).Error("Update item error", "err", err)
+				writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to update item")
+			}
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// itemIDFromPath 從路由的 {id} 取出 item ID，格式錯誤時寫入 400 並返回 false
+func itemIDFromPath(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, CodeInvalidParameter, "Invalid item ID", FieldError{Field: "id", Code: "invalid", Message: "Must be a positive integer"})
+		return 0, false
+	}
+	return id, true
 }
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"http-server/logging"
+	"http-server/models"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGetItemsCursorPagination(t *testing.T) {
+	c := newTestController(t, nil)
+	for _, value := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+		if _, err := c.Items.AddItem(value); err != nil {
+			t.Fatalf("AddItem: %v", err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "id", want: []string{"delta", "alpha", "echo", "charlie", "bravo"}},
+		{sort: "-id", want: []string{"bravo", "charlie", "echo", "alpha", "delta"}},
+		{sort: "value", want: []string{"alpha", "bravo", "charlie", "delta", "echo"}},
+		{sort: "-value", want: []string{"echo", "delta", "charlie", "bravo", "alpha"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			// 依照 next_cursor 逐頁讀取，直到沒有下一頁
+			var got []string
+			cursor := ""
+			for page := 1; ; page++ {
+				if page > len(tt.want) {
+					t.Fatalf("pagination did not end after %d pages", page-1)
+				}
+				params := url.Values{"limit": {"2"}, "sort": {tt.sort}}
+				if cursor != "" {
+					params.Set("cursor", cursor)
+				}
+				rec := serve(http.HandlerFunc(c.GetItemsHandler), httptest.NewRequest(http.MethodGet, "/api/items?"+params.Encode(), nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("page %d: status %d, body %s", page, rec.Code, rec.Body)
+				}
+
+				var resp ItemListResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if resp.Total != len(tt.want) {
+					t.Errorf("page %d: total = %d, want %d", page, resp.Total, len(tt.want))
+				}
+				for _, item := range resp.Items {
+					got = append(got, item.Value)
+				}
+				if resp.NextCursor == "" {
+					break
+				}
+				cursor = resp.NextCursor
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("items = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetItemsInvalidCursor(t *testing.T) {
+	c := newTestController(t, nil)
+	for _, value := range []string{"a", "b", "c"} {
+		c.Items.AddItem(value)
+	}
+
+	rec := serve(http.HandlerFunc(c.GetItemsHandler), httptest.NewRequest(http.MethodGet, "/api/items?limit=1", nil))
+	var resp ItemListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.NextCursor == "" {
+		t.Fatalf("first page: %v, next_cursor %q", err, resp.NextCursor)
+	}
+
+	tests := []struct {
+		name  string
+		query url.Values
+	}{
+		{name: "malformed", query: url.Values{"cursor": {"%%%"}}},
+		{name: "different sort", query: url.Values{"cursor": {resp.NextCursor}, "sort": {"-id"}}},
+		{name: "cursor with page", query: url.Values{"cursor": {resp.NextCursor}, "page": {"2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(http.HandlerFunc(c.GetItemsHandler), httptest.NewRequest(http.MethodGet, "/api/items?"+tt.query.Encode(), nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+			if got := decodeAPIError(t, rec).Code; got != CodeInvalidParameter {
+				t.Errorf("code = %q, want %q", got, CodeInvalidParameter)
+			}
+		})
+	}
+}
+
+// failingItems 模擬資料庫故障，所有操作都返回錯誤
+type failingItems struct{}
+
+var errDatabaseDown = errors.New("database is down")
+
+func (failingItems) QueryItems(models.ItemQuery) ([]models.Item, int, bool, error) {
+	return nil, 0, false, errDatabaseDown
+}
+func (failingItems) GetItemByID(int) (*models.Item, error) { return nil, errDatabaseDown }
+func (failingItems) AddItem(string) (int, error)           { return 0, errDatabaseDown }
+func (failingItems) DeleteItem(int) error                  { return errDatabaseDown }
+func (failingItems) UpdateItem(int, string) error          { return errDatabaseDown }
+
+func TestItemHandlersLogDatabaseErrors(t *testing.T) {
+	c := newTestController(t, nil)
+	c.Items = failingItems{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/items", c.GetItemsHandler)
+	mux.HandleFunc("POST /api/items", c.AddItemHandler)
+	mux.HandleFunc("GET /api/items/{id}", c.GetItemHandler)
+	mux.HandleFunc("PUT /api/items/{id}", c.UpdateItemHandler)
+	mux.HandleFunc("PATCH /api/items/{id}", c.PatchItemHandler)
+	mux.HandleFunc("DELETE /api/items/{id}", c.DeleteItemHandler)
+
+	tests := []struct {
+		method, target string
+		body           any
+	}{
+		{method: http.MethodGet, target: "/api/items"},
+		{method: http.MethodPost, target: "/api/items", body: models.Item{Value: "a"}},
+		{method: http.MethodGet, target: "/api/items/1"},
+		{method: http.MethodPut, target: "/api/items/1", body: models.Item{Value: "a"}},
+		{method: http.MethodPatch, target: "/api/items/1", body: map[string]string{"value": "a"}},
+		{method: http.MethodDelete, target: "/api/items/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			var logs bytes.Buffer
+			req := newJSONRequest(t, tt.method, tt.target, tt.body)
+			req = req.WithContext(logging.WithLogger(req.Context(), slog.New(slog.NewTextHandler(&logs, nil))))
+
+			rec := serve(mux, req)
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusInternalServerError, rec.Body)
+			}
+			// 錯誤的原因只記錄在日誌中，不返回給客戶端
+			if !strings.Contains(logs.String(), errDatabaseDown.Error()) {
+				t.Errorf("error not logged; logs: %q", logs.String())
+			}
+			if strings.Contains(rec.Body.String(), errDatabaseDown.Error()) {
+				t.Errorf("error leaked to response: %s", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginGuard 記錄每個用戶名與 IP 的登入失敗次數，用於防止暴力破解
+//
+//	login.max_attempts         同一用戶名連續失敗幾次後鎖定（預設 5）
+//	login.ip_max_attempts      同一 IP 連續失敗幾次後鎖定（預設 20）
+//	login.backoff_base         第一次失敗後需要等待的時間，之後每次失敗加倍（預設 1s）
+//	login.lockout_duration     鎖定時間，也是失敗紀錄的保存時間（預設 15m）
+//	login.trust_forwarded_for  位於反向代理之後時，使用 X-Forwarded-For 最右邊的 IP（預設 false）
+type loginGuard struct {
+	mu        sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+}
+
+type loginAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// loginLimits 是當前的登入限制設定
+type loginLimits struct {
+	maxAttempts   int
+	ipMaxAttempts int
+	backoffBase   time.Duration
+	lockout       time.Duration
+}
+
+func (c *Controller) currentLoginLimits() loginLimits {
+	return loginLimits{
+		maxAttempts:   c.Config.GetInt("login.max_attempts", 5),
+		ipMaxAttempts: c.Config.GetInt("login.ip_max_attempts", 20),
+		backoffBase:   c.Config.GetDuration("login.backoff_base", time.Second),
+		lockout:       c.Config.GetDuration("login.lockout_duration", 15*time.Minute),
+	}
+}
+
+// retryAfter 返回 keys 中最晚解除封鎖的剩餘時間，0 表示可以嘗試登入
+func (g *loginGuard) retryAfter(keys ...string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys {
+		if a, ok := g.attempts[key]; ok && a.blockedUntil.After(now) {
+			wait = max(wait, a.blockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// recordFailure 記錄一次失敗，並依失敗次數計算下次可嘗試的時間
+// 未達上限時指數退避（base, 2*base, 4*base...，不超過鎖定時間），達到上限時鎖定
+func (g *loginGuard) recordFailure(key string, maxAttempts int, limits loginLimits) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.prune(now, limits.lockout)
+
+	a, ok := g.attempts[key]
+	if !ok || now.Sub(a.lastFailure) > limits.lockout {
+		a = &loginAttempts{}
+		g.attempts[key] = a
+	}
+	a.failures++
+	a.lastFailure = now
+
+	if maxAttempts > 0 && a.failures >= maxAttempts {
+		a.blockedUntil = now.Add(limits.lockout)
+		return
+	}
+	backoff := time.Duration(float64(limits.backoffBase) * math.Pow(2, float64(a.failures-1)))
+	a.blockedUntil = now.Add(min(backoff, limits.lockout))
+}
+
+// reset 清除失敗紀錄，登入成功時呼叫
+func (g *loginGuard) reset(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.attempts, key)
+}
+
+// prune 每分鐘最多一次，清除已過期的失敗紀錄，避免 map 無限增長
+func (g *loginGuard) prune(now time.Time, ttl time.Duration) {
+	if now.Sub(g.lastPrune) < time.Minute {
+		return
+	}
+	g.lastPrune = now
+	for key, a := range g.attempts {
+		if now.Sub(a.lastFailure) > ttl && !a.blockedUntil.After(now) {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+// dummyPasswordHash 用於用戶不存在時仍執行一次 bcrypt 比對，
+// 讓回應時間與密碼錯誤時一致，避免透過時間差判斷用戶名是否存在
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	return string(hash)
+})
+
+// remoteIP 取得請求來源 IP
+// X-Forwarded-For 可以被客戶端偽造，只有設定 login.trust_forwarded_for 時才使用
+// 客戶端可以自行填入左邊的項目，因此只採用最右邊、由前方的反向代理加上的項目
+func (c *Controller) remoteIP(r *http.Request) string {
+	if c.Config.GetBool("login.trust_forwarded_for", false) {
+		// 標頭可能出現多次，最後一個標頭的最後一項才是代理加上的
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			last := values[len(values)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if ip := strings.TrimSpace(last); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
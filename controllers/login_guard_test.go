@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tryLogin 以 remoteAddr 嘗試登入並返回響應
+func tryLogin(t *testing.T, c *Controller, username, password, remoteAddr string) (int, string) {
+	t.Helper()
+	req := newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: username, Password: password})
+	req.RemoteAddr = remoteAddr
+	rec := serve(http.HandlerFunc(c.LoginHandler), req)
+	if rec.Code == http.StatusTooManyRequests {
+		if got := decodeAPIError(t, rec).Code; got != CodeTooManyAttempts {
+			t.Errorf("code = %q, want %q", got, CodeTooManyAttempts)
+		}
+	}
+	return rec.Code, rec.Header().Get("Retry-After")
+}
+
+func TestLoginBackoff(t *testing.T) {
+	c := newTestController(t, map[string]string{"login.backoff_base": "1m"})
+	createUser(t, c, "alice", testPassword, "87")
+
+	if status, _ := tryLogin(t, c, "alice", "wrong-password", "192.0.2.1:1234"); status != http.StatusUnauthorized {
+		t.Fatalf("wrong password: status = %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	// 退避期間即使密碼正確也拒絕，並告知需要等待的秒數
+	status, retryAfter := tryLogin(t, c, "alice", testPassword, "192.0.2.1:1234")
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("during backoff: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if retryAfter != "60" {
+		t.Errorf("Retry-After = %q, want %q", retryAfter, "60")
+	}
+
+	// 同一 IP 的其他用戶不受影響
+	createUser(t, c, "bob", testPassword, "87")
+	if status, _ := tryLogin(t, c, "bob", testPassword, "192.0.2.1:1234"); status != http.StatusOK {
+		t.Errorf("other user: status = %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	c := newTestController(t, map[string]string{
+		"login.backoff_base":     "0s",
+		"login.max_attempts":     "3",
+		"login.lockout_duration": "10m",
+	})
+	createUser(t, c, "alice", testPassword, "87")
+
+	for i := 1; i <= 3; i++ {
+		if status, _ := tryLogin(t, c, "alice", "wrong-password", "192.0.2.1:1234"); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want %d", i, status, http.StatusUnauthorized)
+		}
+	}
+
+	// 用戶名區分大小寫登入時也視為同一個用戶
+	status, retryAfter := tryLogin(t, c, "ALICE", testPassword, "198.51.100.7:1234")
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("after lockout: status = %d, want %d", status, http.StatusTooManyRequests)
+	}
+	if retryAfter != "600" {
+		t.Errorf("Retry-After = %q, want %q", retryAfter, "600")
+	}
+}
+
+func TestLoginIPLockout(t *testing.T) {
+	c := newTestController(t, map[string]string{
+		"login.backoff_base":    "0s",
+		"login.ip_max_attempts": "2",
+	})
+	createUser(t, c, "alice", testPassword, "87")
+
+	// 同一 IP 對不同用戶名的失敗也會累計
+	tryLogin(t, c, "nobody1", "wrong-password", "192.0.2.1:1234")
+	tryLogin(t, c, "nobody2", "wrong-password", "192.0.2.1:1234")
+
+	if status, retryAfter := tryLogin(t, c, "alice", testPassword, "192.0.2.1:5678"); status != http.StatusTooManyRequests || retryAfter == "" {
+		t.Errorf("same IP: status = %d, Retry-After %q; want %d with Retry-After", status, retryAfter, http.StatusTooManyRequests)
+	}
+	if status, _ := tryLogin(t, c, "alice", testPassword, "198.51.100.7:1234"); status != http.StatusOK {
+		t.Errorf("other IP: status = %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     string
+		forwarded []string
+		want      string
+	}{
+		{name: "remote addr", trust: "false", want: "192.0.2.1"},
+		{name: "forwarded ignored by default", trust: "false", forwarded: []string{"203.0.113.9"}, want: "192.0.2.1"},
+		{name: "single entry", trust: "true", forwarded: []string{"203.0.113.9"}, want: "203.0.113.9"},
+		{name: "client-supplied entries", trust: "true", forwarded: []string{"198.51.100.1, 203.0.113.9"}, want: "203.0.113.9"},
+		{name: "multiple headers", trust: "true", forwarded: []string{"198.51.100.1", "203.0.113.9"}, want: "203.0.113.9"},
+		{name: "empty header", trust: "true", forwarded: []string{""}, want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestController(t, map[string]string{"login.trust_forwarded_for": tt.trust})
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if got := c.remoteIP(req); got != tt.want {
+				t.Errorf("remoteIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginIPLockoutIgnoresSpoofedForwardedFor(t *testing.T) {
+	c := newTestController(t, map[string]string{
+		"login.backoff_base":        "0s",
+		"login.ip_max_attempts":     "2",
+		"login.trust_forwarded_for": "true",
+	})
+	createUser(t, c, "alice", testPassword, "87")
+
+	// 每次偽造不同的左側 IP，代理加上的 IP 相同，仍然累計在同一個 IP
+	for i, spoofed := range []string{"198.51.100.1", "198.51.100.2"} {
+		req := newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: "nobody", Password: "wrong-password"})
+		req.Header.Set("X-Forwarded-For", spoofed+", 203.0.113.9")
+		if rec := serve(http.HandlerFunc(c.LoginHandler), req); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want %d", i+1, rec.Code, http.StatusUnauthorized)
+		}
+	}
+
+	req := newJSONRequest(t, http.MethodPost, "/auth/login", LoginRequest{Username: "alice", Password: testPassword})
+	req.Header.Set("X-Forwarded-For", "198.51.100.3, 203.0.113.9")
+	if rec := serve(http.HandlerFunc(c.LoginHandler), req); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
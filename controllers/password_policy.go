@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"http-server/logging"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt 只會使用密碼的前 72 個位元組，超過的部分會被忽略
+const bcryptMaxBytes = 72
+
+// PasswordPolicy 是密碼規則，透過 config 表設定，每次檢查時讀取，修改後立即生效
+//
+//	password.min_length      最短字元數（預設 8）
+//	password.max_length      最長位元組數（預設且最多 72）
+//	password.require_upper   需要大寫字母（預設 false）
+//	password.require_lower   需要小寫字母（預設 false）
+//	password.require_digit   需要數字（預設 false）
+//	password.require_symbol  需要符號（預設 false）
+//	password.history         不可與最近 N 次使用過的密碼相同（預設 5，0 表示不檢查）
+//	password.blocklist_file  常見／外洩密碼清單的路徑（預設 data/common-passwords.txt）
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	History       int
+	BlocklistFile string
+
+	blocklist *passwordBlocklist
+}
+
+// currentPasswordPolicy 從 ConfigManager 讀取密碼規則，未設定的項目使用預設值
+func (c *Controller) currentPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:     c.Config.GetInt("password.min_length", 8),
+		MaxLength:     c.Config.GetInt("password.max_length", bcryptMaxBytes),
+		RequireUpper:  c.Config.GetBool("password.require_upper", false),
+		RequireLower:  c.Config.GetBool("password.require_lower", false),
+		RequireDigit:  c.Config.GetBool("password.require_digit", false),
+		RequireSymbol: c.Config.GetBool("password.require_symbol", false),
+		History:       c.Config.GetInt("password.history", 5),
+		BlocklistFile: c.Config.GetString("password.blocklist_file", "data/common-passwords.txt"),
+		blocklist:     c.blocklist,
+	}
+	if policy.MaxLength <= 0 || policy.MaxLength > bcryptMaxBytes {
+		policy.MaxLength = bcryptMaxBytes
+	}
+	return policy
+}
+
+// Check 檢查密碼是否符合規則，field 為錯誤訊息中使用的欄位名稱
+// 與歷史密碼的比對需要查詢資料庫，另由 checkPasswordHistory 處理；ctx 用於記錄密碼清單的載入錯誤
+func (p PasswordPolicy) Check(ctx context.Context, field, username, password string) []FieldError {
+	var fields []FieldError
+	fail := func(code, format string, args ...interface{}) {
+		fields = append(fields, FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		fail("too_short", "%s must be at least %d characters", field, p.MinLength)
+	}
+	if len(password) > p.MaxLength {
+		fail("too_long", "%s must be at most %d bytes", field, p.MaxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		fail("missing_upper", "%s must contain an uppercase letter", field)
+	}
+	if p.RequireLower && !hasLower {
+		fail("missing_lower", "%s must contain a lowercase letter", field)
+	}
+	if p.RequireDigit && !hasDigit {
+		fail("missing_digit", "%s must contain a digit", field)
+	}
+	if p.RequireSymbol && !hasSymbol {
+		fail("missing_symbol", "%s must contain a symbol", field)
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		fail("same_as_username", "%s must not be the same as the username", field)
+	}
+	if p.blocklist != nil && p.blocklist.contains(ctx, p.BlocklistFile, password) {
+		fail("common_password", "%s is too common or has appeared in a data breach", field)
+	}
+	return fields
+}
+
+// checkPasswordHistory 確認新密碼與最近使用過的密碼雜湊都不相同
+func (p PasswordPolicy) checkPasswordHistory(field, password string, hashes []string) []FieldError {
+	for _, hash := range hashes {
+		if CheckPasswordHash(password, hash) {
+			return []FieldError{{Field: field, Code: "reused", Message: fmt.Sprintf("%s must not match any of the last %d passwords", field, p.History)}}
+		}
+	}
+	return nil
+}
+
+// passwordBlocklist 快取已載入的密碼清單，路徑改變時重新載入
+type passwordBlocklist struct {
+	mu    sync.Mutex
+	path  string
+	words map[string]struct{}
+}
+
+// contains 判斷密碼是否在清單中（不分大小寫）；清單無法讀取時不阻擋
+func (b *passwordBlocklist) contains(ctx context.Context, path, password string) bool {
+	if path == "" {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.words == nil || b.path != path {
+		words, err := loadPasswordBlocklist(path)
+		if err != nil {
+			logging.FromContext(ctx).Error("Password blocklist load error", "path", path, "err", err)
+		}
+		b.path = path
+		b.words = words
+	}
+
+	_, found := b.words[strings.ToLower(password)]
+	return found
+}
+
+// loadPasswordBlocklist 讀取密碼清單，每行一個密碼，# 開頭為註解
+func loadPasswordBlocklist(path string) (map[string]struct{}, error) {
+	words := make(map[string]struct{})
+	file, err := os.Open(path)
+	if err != nil {
+		return words, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words[strings.ToLower(line)] = struct{}{}
+	}
+	return words, scanner.Err()
+}
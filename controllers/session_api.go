@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"encoding/json"
+	"http-server/logging"
+	"http-server/sessionstore"
+	"net/http"
+	"strings"
+)
+
+// SessionResponse 是返回給用戶的 Session 資訊，Current 表示是否為發出此請求的 Session
+type SessionResponse struct {
+	sessionstore.SessionInfo
+	Current bool `json:"current"`
+}
+
+// 查詢當前用戶所有登入中的 Session
+func (c *Controller) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, ok := identityFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
+		return
+	}
+
+	list, err := c.Sessions.ListUserSessions(id.Username)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("List sessions error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Session Database error")
+		return
+	}
+
+	session, _ := c.Sessions.Get(r, "session-name")
+	resp := make([]SessionResponse, 0, len(list))
+	for _, info := range list {
+		resp = append(resp, SessionResponse{SessionInfo: info, Current: info.ID == session.ID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 撤銷當前用戶的某一個 Session
+func (c *Controller) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	// 從 URL 中提取 Session ID，例如 /auth/sessions/ABC 中提取到 "ABC"
+	sessionID := strings.TrimPrefix(r.URL.Path, "/auth/sessions/")
+	if sessionID == "" {
+		writeError(w, http.StatusBadRequest, CodeMissingParameter, "Missing session ID", FieldError{Field: "id", Code: "required", Message: "Session ID is required"})
+		return
+	}
+
+	id, ok := identityFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
+		return
+	}
+
+	if err := c.Sessions.Revoke(id.Username, sessionID); err != nil {
+		if err == sessionstore.ErrNotFound {
+			writeError(w, http.StatusNotFound, CodeSessionNotFound, "Session not found")
+		} else {
+			logging.FromContext(r.Context()).Error("Revoke session error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to revoke session")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 撤銷當前用戶除了此 Session 之外的所有 Session（登出其他裝置）
+func (c *Controller) RevokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	id, ok := identityFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, CodeUnauthenticated, "未登入")
+		return
+	}
+
+	if err := c.Sessions.RevokeAll(id.Username, c.currentSessionID(r, id.Username)); err != nil {
+		logging.FromContext(r.Context()).Error("Revoke session error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to revoke sessions")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentSessionID 返回此請求所屬 username 的 Session ID；使用 Bearer Token 或 Session 屬於他人時返回空字串
+func (c *Controller) currentSessionID(r *http.Request, username string) string {
+	session, _ := c.Sessions.Get(r, "session-name")
+	if sessionUser, _ := session.Values["username"].(string); sessionUser != username {
+		return ""
+	}
+	return session.ID
+}
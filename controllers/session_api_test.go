@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// listSessions 返回 cookie 所屬用戶的 Session 列表
+func listSessions(t *testing.T, c *Controller, cookie *http.Cookie) []SessionResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/auth/sessions", nil)
+	req.AddCookie(cookie)
+	rec := serve(c.Authenticate(http.HandlerFunc(c.ListSessionsHandler)), req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list sessions: status %d, body %s", rec.Code, rec.Body)
+	}
+	var list []SessionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return list
+}
+
+// isLoggedIn 確認 cookie 是否仍然可以通過 Authenticate
+func isLoggedIn(c *Controller, cookie *http.Cookie) bool {
+	req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	req.AddCookie(cookie)
+	return serve(c.Authenticate(http.HandlerFunc(c.MeHandler)), req).Code == http.StatusOK
+}
+
+func TestRevokeSession(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	laptop := login(t, c, "alice", testPassword)
+	phone := login(t, c, "alice", testPassword)
+
+	list := listSessions(t, c, laptop)
+	if len(list) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(list))
+	}
+	var other string
+	for _, s := range list {
+		if !s.Current {
+			other = s.ID
+		}
+	}
+	if other == "" {
+		t.Fatal("no session other than the current one")
+	}
+
+	revoke := func(id string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/auth/sessions/"+id, nil)
+		req.AddCookie(laptop)
+		return serve(c.Authenticate(http.HandlerFunc(c.RevokeSessionHandler)), req).Code
+	}
+	if status := revoke(other); status != http.StatusNoContent {
+		t.Fatalf("revoke: status = %d, want %d", status, http.StatusNoContent)
+	}
+	if isLoggedIn(c, phone) {
+		t.Error("revoked session is still logged in")
+	}
+	if !isLoggedIn(c, laptop) {
+		t.Error("current session was logged out")
+	}
+
+	// 已撤銷或不存在的 Session
+	if status := revoke(other); status != http.StatusNotFound {
+		t.Errorf("revoke again: status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestRevokeOtherSessions(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "bob", testPassword, "87")
+	current := login(t, c, "alice", testPassword)
+	others := []*http.Cookie{login(t, c, "alice", testPassword), login(t, c, "alice", testPassword)}
+	bob := login(t, c, "bob", testPassword)
+
+	req := httptest.NewRequest(http.MethodDelete, "/auth/sessions", nil)
+	req.AddCookie(current)
+	if rec := serve(c.Authenticate(http.HandlerFunc(c.RevokeOtherSessionsHandler)), req); rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d; body %s", rec.Code, http.StatusNoContent, rec.Body)
+	}
+
+	for i, cookie := range others {
+		if isLoggedIn(c, cookie) {
+			t.Errorf("other session %d is still logged in", i)
+		}
+	}
+	if !isLoggedIn(c, current) {
+		t.Error("current session was logged out")
+	}
+	// 不影響其他用戶
+	if !isLoggedIn(c, bob) {
+		t.Error("another user's session was logged out")
+	}
+}
+
+func TestRevokeSessionOfAnotherUser(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	createUser(t, c, "bob", testPassword, "87")
+	alice := login(t, c, "alice", testPassword)
+	bob := login(t, c, "bob", testPassword)
+
+	bobSession := listSessions(t, c, bob)[0].ID
+	req := httptest.NewRequest(http.MethodDelete, "/auth/sessions/"+bobSession, nil)
+	req.AddCookie(alice)
+	if status := serve(c.Authenticate(http.HandlerFunc(c.RevokeSessionHandler)), req).Code; status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+	if !isLoggedIn(c, bob) {
+		t.Error("another user's session was revoked")
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"http-server/logging"
+	"http-server/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Token 的類型與有效期限
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+	accessTokenTTL   = 15 * time.Minute
+	refreshTokenTTL  = 7 * 24 * time.Hour
+)
+
+// TokenClaims 是簽入 JWT 的用戶資訊
+// Version 是簽發時用戶的 token_version，與資料庫不同時表示 Token 已被撤銷
+type TokenClaims struct {
+	UserID    int    `json:"uid"`
+	Username  string `json:"username"`
+	RoleID    int    `json:"rid"`
+	TokenType string `json:"typ"`
+	Version   int    `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+// TokenResponse 是登入或刷新 Token 時返回給客戶端的內容
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // Access Token 的剩餘秒數
+}
+
+// issueTokens 簽發一組 Access Token 與 Refresh Token
+// Refresh Token 的 jti 保存在 refresh_tokens 表中，使用一次後即失效
+func (c *Controller) issueTokens(user *models.User, roleID int) (*TokenResponse, error) {
+	now := time.Now()
+	accessToken, err := c.signToken(newTokenClaims(user, roleID, accessTokenType, "", now, accessTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshClaims := newTokenClaims(user, roleID, refreshTokenType, jti, now, refreshTokenTTL)
+	refreshToken, err := c.signToken(refreshClaims)
+	if err != nil {
+		return nil, err
+	}
+	err = c.RefreshTokens.AddRefreshToken(&models.RefreshToken{
+		JTI:       jti,
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: refreshClaims.ExpiresAt.Time,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func newTokenClaims(user *models.User, roleID int, tokenType, jti string, now time.Time, ttl time.Duration) *TokenClaims {
+	return &TokenClaims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		RoleID:    roleID,
+		TokenType: tokenType,
+		Version:   user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+}
+
+func (c *Controller) signToken(claims *TokenClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(c.JWTSecret)
+}
+
+// newTokenID 產生隨機的 Token ID（jti）
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// parseToken 驗證 Token 的簽名、有效期限與類型
+func (c *Controller) parseToken(tokenString, tokenType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return c.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != tokenType {
+		return nil, errors.New("unexpected token type")
+	}
+	return claims, nil
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshTokenHandler 使用 Refresh Token 換發一組新的 Token
+// 使用過的 Refresh Token 立即失效，客戶端必須改用響應中新的 Refresh Token
+func (c *Controller) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Invalid request method")
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequestBody, "Invalid request body")
+		return
+	}
+
+	claims, err := c.parseToken(req.RefreshToken, refreshTokenType)
+	if err != nil || claims.ID == "" {
+		writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Invalid refresh token")
+		return
+	}
+
+	// 重新查詢用戶，確保帳號仍存在、Token 未被撤銷，並取得最新的角色
+	user, err := c.Users.GetUserByUsername(claims.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Invalid refresh token")
+		} else {
+			logging.FromContext(r.Context()).Error("User lookup error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "User Database error")
+		}
+		return
+	}
+	if user.ID != claims.UserID || user.TokenVersion != claims.Version {
+		writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Invalid refresh token")
+		return
+	}
+
+	// 使 Refresh Token 失效；已經使用過的 Token 不能再換發
+	if err := c.RefreshTokens.ConsumeRefreshToken(claims.ID); err != nil {
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusUnauthorized, CodeInvalidToken, "Refresh token has already been used or revoked")
+		} else {
+			logging.FromContext(r.Context()).Error("Refresh token error", "err", err)
+			writeError(w, http.StatusInternalServerError, CodeDatabaseError, "Refresh token Database error")
+		}
+		return
+	}
+	roleID, err := strconv.Atoi(user.RoleID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Invalid user role", "role_id", user.RoleID, "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Invalid user role")
+		return
+	}
+
+	tokens, err := c.issueTokens(user, roleID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Token sign error", "err", err)
+		writeError(w, http.StatusInternalServerError, CodeInternalError, "Failed to issue token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
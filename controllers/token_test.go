@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	c := newTestController(t, nil)
+	createUser(t, c, "alice", testPassword, "87")
+	tokens := loginToken(t, c, "alice", testPassword)
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		return serve(http.HandlerFunc(c.RefreshTokenHandler), newJSONRequest(t, http.MethodPost, "/auth/token/refresh", RefreshTokenRequest{RefreshToken: token}))
+	}
+
+	rec := refresh(tokens.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d, body %s", rec.Code, rec.Body)
+	}
+	var rotated TokenResponse
+	if err := json.NewDecoder(rec.Body).Decode(&rotated); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rotated.RefreshToken == tokens.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// 使用過的 Refresh Token 不能再使用
+	if rec := refresh(tokens.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := refresh(rotated.RefreshToken); rec.Code != http.StatusOK {
+		t.Errorf("rotated refresh token: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
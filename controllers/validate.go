@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// validator 可由請求結構實作，用來檢查 struct tag 無法表達的規則（例如欄位之間的關係）
+type validator interface {
+	Validate() []FieldError
+}
+
+// dateLayout 是 API 中日期欄位的格式
+const dateLayout = "2006-01-02"
+
+// decodeAndValidate 嚴格解析 JSON 請求體並驗證欄位
+// 不允許未知欄位；解析或驗證失敗時寫入錯誤響應並返回 false，所有欄位錯誤會一次返回
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if !decodeJSON(w, r, dst) {
+		return false
+	}
+	return checkFields(w, validateStruct(dst))
+}
+
+// decodeJSON 嚴格解析 JSON 請求體但不驗證欄位，供需要加入其他檢查（例如密碼規則）的處理函數使用
+// 解析失敗時寫入錯誤響應並返回 false
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequestBody, describeDecodeError(err))
+		return false
+	}
+	// 請求體只能包含一個 JSON 值
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequestBody, "Request body must contain a single JSON object")
+		return false
+	}
+	return true
+}
+
+// checkFields 有欄位錯誤時以一個 400 響應返回所有錯誤，並返回 false
+func checkFields(w http.ResponseWriter, fields []FieldError) bool {
+	if len(fields) > 0 {
+		writeError(w, http.StatusBadRequest, CodeValidationFailed, "Request validation failed", fields...)
+		return false
+	}
+	return true
+}
+
+// describeDecodeError 將 JSON 解析錯誤轉成給人看的說明
+func describeDecodeError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("Field %q must be %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "Unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	case err == io.EOF:
+		return "Request body is empty"
+	default:
+		return "Invalid request body"
+	}
+}
+
+// validateStruct 依照欄位的 validate tag 驗證結構，並呼叫 Validate() 檢查其他規則
+//
+// 支援的規則（以逗號分隔）：
+//
+//	required      不可為空字串
+//	omitempty     值為空時略過其他規則
+//	min=N,max=N   字元數範圍
+//	email         Email 格式
+//	oneof=a b c   只能是列出的值之一
+//	identifier    只能包含英數字、底線、點與連字號
+//	date          YYYY-MM-DD 格式
+//	mindate=D     日期不可早於 D
+//	past          日期不可晚於今天
+func validateStruct(v interface{}) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		// 指標為 nil 表示未提供（例如 PATCH），略過驗證
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() != reflect.String {
+			continue
+		}
+
+		if fe, ok := validateField(jsonFieldName(sf), fv.String(), tag); !ok {
+			fields = append(fields, fe)
+		}
+	}
+
+	if val, ok := v.(validator); ok {
+		fields = append(fields, val.Validate()...)
+	}
+	return fields
+}
+
+// validateField 依序檢查規則，返回第一個不符合的規則
+func validateField(name, value, tag string) (FieldError, bool) {
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if rule == "omitempty" && value == "" {
+			return FieldError{}, true
+		}
+	}
+
+	fail := func(code, format string, args ...interface{}) (FieldError, bool) {
+		return FieldError{Field: name, Code: code, Message: fmt.Sprintf(format, args...)}, false
+	}
+
+	for _, rule := range rules {
+		key, arg, _ := strings.Cut(rule, "=")
+		switch key {
+		case "omitempty":
+		case "required":
+			if strings.TrimSpace(value) == "" {
+				return fail("required", "%s is required", name)
+			}
+		case "min":
+			n, _ := strconv.Atoi(arg)
+			if utf8.RuneCountInString(value) < n {
+				return fail("too_short", "%s must be at least %d characters", name, n)
+			}
+		case "max":
+			n, _ := strconv.Atoi(arg)
+			if utf8.RuneCountInString(value) > n {
+				return fail("too_long", "%s must be at most %d characters", name, n)
+			}
+		case "email":
+			addr, err := mail.ParseAddress(value)
+			if err != nil || addr.Address != value {
+				return fail("invalid_format", "%s must be a valid email address", name)
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			found := false
+			for _, option := range options {
+				if value == option {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fail("invalid_choice", "%s must be one of %s", name, strings.Join(options, ", "))
+			}
+		case "identifier":
+			for _, c := range value {
+				if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+					return fail("invalid_format", "%s may only contain letters, digits, '_', '.' and '-'", name)
+				}
+			}
+		case "date":
+			if _, err := time.Parse(dateLayout, value); err != nil {
+				return fail("invalid_format", "%s must be a date in YYYY-MM-DD format", name)
+			}
+		case "mindate":
+			date, err := time.Parse(dateLayout, value)
+			min, _ := time.Parse(dateLayout, arg)
+			if err == nil && date.Before(min) {
+				return fail("out_of_range", "%s must not be before %s", name, arg)
+			}
+		case "past":
+			date, err := time.Parse(dateLayout, value)
+			if err == nil && date.After(time.Now()) {
+				return fail("out_of_range", "%s must not be in the future", name)
+			}
+		default:
+			panic(fmt.Sprintf("unknown validation rule %q on field %s", rule, name))
+		}
+	}
+	return FieldError{}, true
+}
+
+// jsonFieldName 返回欄位在 JSON 中的名稱，讓錯誤訊息與請求內容一致
+func jsonFieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
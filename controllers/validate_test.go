@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidateField(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(dateLayout)
+
+	tests := []struct {
+		name  string
+		value string
+		tag   string
+		want  string // 不符合的規則代碼，空字串表示通過
+	}{
+		{name: "required", value: "", tag: "required", want: "required"},
+		{name: "required spaces", value: "   ", tag: "required", want: "required"},
+		{name: "required ok", value: "alice", tag: "required"},
+		{name: "omitempty skips rules", value: "", tag: "omitempty,email"},
+		{name: "min", value: "ab", tag: "min=3", want: "too_short"},
+		{name: "min counts runes", value: "王小明", tag: "min=3"},
+		{name: "max", value: "abcd", tag: "max=3", want: "too_long"},
+		{name: "max counts runes", value: "王小明", tag: "max=3"},
+		{name: "email", value: "alice@example.com", tag: "email"},
+		{name: "email invalid", value: "not-an-email", tag: "email", want: "invalid_format"},
+		{name: "email with display name", value: "Alice <alice@example.com>", tag: "email", want: "invalid_format"},
+		{name: "oneof", value: "other", tag: "oneof=male female other"},
+		{name: "oneof invalid", value: "unknown", tag: "oneof=male female other", want: "invalid_choice"},
+		{name: "identifier", value: "alice_01.b-c", tag: "identifier"},
+		{name: "identifier invalid", value: "alice bob", tag: "identifier", want: "invalid_format"},
+		{name: "date", value: "2000-02-29", tag: "date"},
+		{name: "date invalid", value: "2001-02-29", tag: "date", want: "invalid_format"},
+		{name: "date wrong layout", value: "29/02/2000", tag: "date", want: "invalid_format"},
+		{name: "mindate", value: "1899-12-31", tag: "date,mindate=1900-01-01", want: "out_of_range"},
+		{name: "mindate boundary", value: "1900-01-01", tag: "date,mindate=1900-01-01"},
+		{name: "past", value: tomorrow, tag: "date,past", want: "out_of_range"},
+		{name: "first failing rule wins", value: "x", tag: "required,min=3,identifier", want: "too_short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe, ok := validateField("field", tt.value, tt.tag)
+			if tt.want == "" {
+				if !ok {
+					t.Errorf("validateField(%q, %q) failed with %q, want ok", tt.value, tt.tag, fe.Code)
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("validateField(%q, %q) = ok, want %q", tt.value, tt.tag, tt.want)
+			}
+			if fe.Code != tt.want || fe.Field != "field" {
+				t.Errorf("validateField(%q, %q) = %s:%s, want field:%s", tt.value, tt.tag, fe.Field, fe.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStructReportsEveryField(t *testing.T) {
+	req := &ChangePasswordRequest{OldPassword: "", NewPassword: ""}
+	got := fieldCodes(APIError{Errors: validateStruct(req)})
+	want := []string{"oldPassword:required", "newPassword:required"}
+	if !slices.Equal(got, want) {
+		t.Errorf("validateStruct = %v, want %v", got, want)
+	}
+
+	// Validate() 的規則與 tag 的規則一起返回
+	req = &ChangePasswordRequest{OldPassword: testPassword, NewPassword: testPassword}
+	if got := fieldCodes(APIError{Errors: validateStruct(req)}); !slices.Equal(got, []string{"newPassword:unchanged"}) {
+		t.Errorf("validateStruct = %v, want [newPassword:unchanged]", got)
+	}
+}
@@ -1,39 +1,122 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
-
-	_ "github.com/go-sql-driver/mysql" // MySQL/MariaDB 驅動
+	"log/slog"
+	"time"
 )
 
-// DB 是全局變量，用於存儲資料庫連線對象
-// 通過 InitDB 初始化後，全局可以使用 DB 來進行資料庫操作
-var DB *sql.DB
+// Options 是連線池與啟動連線的設定
+type Options struct {
+	AutoMigrate     bool          // 套用所有尚未套用的遷移
+	MaxOpenConns    int           // 最大連線數，0 表示不限制；SQLite 固定為 1
+	MaxIdleConns    int           // 保留的閒置連線數
+	ConnMaxLifetime time.Duration // 連線的最長使用時間，0 表示不限制
+	ConnMaxIdleTime time.Duration // 閒置連線關閉前的時間，0 表示不限制
+	ConnectTimeout  time.Duration // 持續重試連線的時間上限，0 表示只嘗試一次
+}
 
-// InitDB 初始化資料庫連線
-func InitDB() {
-	var err error
+// 啟動時重試連線的間隔，每次失敗後加倍直到上限
+const (
+	initialRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
+	pingTimeout         = 5 * time.Second
+)
 
-	// 從環境變數中獲取資料庫連線字串（DSN）
-	dsn := os.Getenv("DATABASE_DSN")
+// InitDB 使用資料庫連線字串（DSN）建立連線池，並確認資料庫可以連線
+// DSN 的 scheme（mysql://、postgres://、sqlite://）決定使用的資料庫，見 ParseDSN
+// 資料庫尚未就緒時（例如與伺服器同時啟動的容器）會在 opts.ConnectTimeout 內重試；返回的 *sql.DB 由呼叫者負責關閉
+func InitDB(dsn string, opts Options) (*sql.DB, error) {
 	if dsn == "" {
-		panic("環境變數 DATABASE_DSN 未設定")
+		return nil, fmt.Errorf("環境變數 DATABASE_DSN 未設定")
+	}
+
+	dialect, driverDSN, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
 	}
 
 	// 使用資料庫連線字串建立連線池
-	DB, err = sql.Open("mysql", dsn)
+	db, err := open(dialect, driverDSN)
 	if err != nil {
-		panic(fmt.Sprintf("資料庫連線失敗: %v", err))
+		return nil, fmt.Errorf("資料庫連線失敗: %w", err)
+	}
+	if dialect != SQLite {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
 	}
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
 
 	// 驗證資料庫連線是否成功
 	// Ping 用於測試資料庫連線是否可用，這可以捕獲任何潛在的連線問題
-	err = DB.Ping()
-	if err != nil {
-		panic(fmt.Sprintf("資料庫無法連線: %v", err))
+	if err := ping(db, opts.ConnectTimeout); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("資料庫無法連線: %w", err)
 	}
 
-	fmt.Println("資料庫連線成功")
-}
\ No newline at end of file
+	slog.Info("資料庫連線成功", "dialect", dialect)
+
+	if opts.AutoMigrate {
+		applied, err := MigrateUp(db)
+		for _, m := range applied {
+			slog.Info("已套用遷移", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("資料庫遷移失敗: %w", err)
+		}
+	}
+	return db, nil
+}
+
+// ping 確認資料庫可以連線，失敗時以遞增的間隔重試，直到超過 timeout
+func ping(db *sql.DB, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	backoff := initialRetryBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		err := db.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		slog.Warn("資料庫無法連線，稍後重試", "attempt", attempt, "retry_in", backoff, "err", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// PoolStats 是連線池的統計，提供給健康檢查與監控使用
+type PoolStats struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration_ns"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+}
+
+// Stats 返回 db 連線池目前的統計
+func Stats(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,64 @@
+package database
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{name: "mysql unchanged", dialect: MySQL, query: "SELECT `key` FROM config WHERE id = ? FOR UPDATE", want: "SELECT `key` FROM config WHERE id = ? FOR UPDATE"},
+		{name: "postgres placeholders", dialect: Postgres, query: "UPDATE users SET password = ? WHERE id = ? AND role_id = ?", want: "UPDATE users SET password = $1 WHERE id = $2 AND role_id = $3"},
+		{name: "postgres backticks", dialect: Postgres, query: "SELECT `key`, value FROM config WHERE `key` = ?", want: `SELECT "key", value FROM config WHERE "key" = $1`},
+		{name: "postgres string literal", dialect: Postgres, query: "SELECT id FROM items WHERE value LIKE ? ESCAPE '!' AND note <> 'why?'", want: "SELECT id FROM items WHERE value LIKE $1 ESCAPE '!' AND note <> 'why?'"},
+		{name: "postgres backtick in string", dialect: Postgres, query: "SELECT '`?`' WHERE id = ?", want: "SELECT '`?`' WHERE id = $1"},
+		{name: "postgres escaped quote", dialect: Postgres, query: "SELECT 'it''s ?' WHERE id = ?", want: "SELECT 'it''s ?' WHERE id = $1"},
+		{name: "postgres keeps FOR UPDATE", dialect: Postgres, query: "SELECT id FROM users WHERE id = ? FOR UPDATE", want: "SELECT id FROM users WHERE id = $1 FOR UPDATE"},
+		{name: "sqlite FOR UPDATE", dialect: SQLite, query: "SELECT id FROM users WHERE id = ? FOR UPDATE", want: "SELECT id FROM users WHERE id = ?"},
+		{name: "sqlite placeholders unchanged", dialect: SQLite, query: "SELECT `key` FROM config WHERE `key` = ?", want: "SELECT `key` FROM config WHERE `key` = ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rebind(tt.dialect, tt.query); got != tt.want {
+				t.Errorf("Rebind(%s, %q)\n got %q\nwant %q", tt.dialect, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn         string
+		wantDialect Dialect
+		wantDSN     string
+		wantErr     bool
+	}{
+		{dsn: "user:pw@tcp(127.0.0.1:3306)/app?parseTime=true", wantDialect: MySQL, wantDSN: "user:pw@tcp(127.0.0.1:3306)/app?parseTime=true"},
+		{dsn: "mysql://user:pw@tcp(127.0.0.1:3306)/app", wantDialect: MySQL, wantDSN: "user:pw@tcp(127.0.0.1:3306)/app"},
+		{dsn: "mysql://user:pw@127.0.0.1:3306/app", wantDialect: MySQL, wantDSN: "user:pw@tcp(127.0.0.1:3306)/app"},
+		{dsn: "postgres://user:pw@127.0.0.1:5432/app", wantDialect: Postgres, wantDSN: "postgres://user:pw@127.0.0.1:5432/app"},
+		{dsn: "sqlite://data/app.db", wantDialect: SQLite, wantDSN: "data/app.db?_foreign_keys=on&_busy_timeout=5000"},
+		{dsn: "sqlite://:memory:?_fk=1", wantDialect: SQLite, wantDSN: ":memory:?_fk=1&_busy_timeout=5000"},
+		{dsn: "sqlite://", wantErr: true},
+		{dsn: "oracle://db", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			dialect, dsn, err := ParseDSN(tt.dsn)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDSN(%q) = %s, %q; want error", tt.dsn, dialect, dsn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dialect != tt.wantDialect || dsn != tt.wantDSN {
+				t.Errorf("ParseDSN(%q) = %s, %q; want %s, %q", tt.dsn, dialect, dsn, tt.wantDialect, tt.wantDSN)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles 是內嵌在執行檔中的資料庫遷移檔，每種資料庫一個目錄（migrations/mysql、migrations/postgres、migrations/sqlite）
+// 檔名格式為 <版本>_<名稱>.up.sql 與 <版本>_<名稱>.down.sql，版本號需遞增且不可重複，各目錄的版本需保持一致
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// Migration 是一個版本的資料庫遷移
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 是一個遷移的套用狀態，AppliedAt 為 nil 表示尚未套用
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// createMigrationsTable 建立記錄已套用版本的 schema_migrations 表，%s 為時間欄位的類型
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    BIGINT       NOT NULL PRIMARY KEY,
+		name       VARCHAR(255) NOT NULL,
+		applied_at %s NOT NULL
+	)
+`
+
+// Migrations 讀取 dialect 的所有內嵌遷移，依版本排序
+func Migrations(dialect Dialect) ([]Migration, error) {
+	return parseMigrations(migrationFiles, "migrations/"+string(dialect))
+}
+
+// parseMigrations 讀取 fsys 中 dir 目錄的遷移檔，依版本排序
+func parseMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		versionText, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// appliedMigrations 查詢已套用的版本與套用時間
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	timeType := "DATETIME"
+	if DialectOf(db) == Postgres {
+		timeType = "TIMESTAMPTZ"
+	}
+	if _, err := db.Exec(fmt.Sprintf(createMigrationsTable, timeType)); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrateUp 依序套用所有尚未套用的遷移，返回本次套用的遷移
+// PostgreSQL 與 SQLite 的每個遷移在交易中執行，失敗時整個遷移回滾；
+// MySQL 的 DDL 無法回滾，遷移中途失敗時需要手動修正後再執行
+func MigrateUp(db *sql.DB) ([]Migration, error) {
+	migrations, err := Migrations(DialectOf(db))
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := runMigration(db, func(q execQueryer) error {
+			if err := execStatements(q, m.Up); err != nil {
+				return err
+			}
+			_, err := q.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s up: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// MigrateDown 依版本由新到舊撤銷最近 steps 個已套用的遷移，返回本次撤銷的遷移
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	migrations, err := Migrations(DialectOf(db))
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+		err := runMigration(db, func(q execQueryer) error {
+			if err := execStatements(q, m.Down); err != nil {
+				return err
+			}
+			_, err := q.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// GetMigrationStatus 返回每個遷移的套用狀態，依版本排序
+func GetMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations(DialectOf(db))
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			s.AppliedAt = &appliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// runMigration 執行一個遷移，支援交易式 DDL 的資料庫在交易中執行
+func runMigration(db *sql.DB, fn func(q execQueryer) error) error {
+	if DialectOf(db) == MySQL {
+		return fn(db)
+	}
+	return WithTx(db, func(tx *sql.Tx) error { return fn(tx) })
+}
+
+// execStatements 逐條執行以分號結尾的 SQL 語句，-- 開頭的行為註解
+// 不需要在 DSN 中開啟 multiStatements；語句中的字串不可包含行尾的分號
+func execStatements(q execQueryer, script string) error {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";\n") {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt == "" {
+			continue
+		}
+		if _, err := q.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		want     []string // 依序為 版本_名稱
+		wantDown []bool
+		wantErr  string
+	}{
+		{
+			name:     "sorted by version",
+			files:    []string{"0010_add_index.up.sql", "0002_create_items.up.sql", "0002_create_items.down.sql", "0001_create_users.up.sql", "0001_create_users.down.sql"},
+			want:     []string{"1_create_users", "2_create_items", "10_add_index"},
+			wantDown: []bool{true, true, false},
+		},
+		{name: "missing up file", files: []string{"0001_create_users.down.sql"}, wantErr: "has no up file"},
+		{name: "missing direction", files: []string{"0001_create_users.sql"}, wantErr: "invalid migration file name"},
+		{name: "unknown direction", files: []string{"0001_create_users.sideways.sql"}, wantErr: "invalid migration file name"},
+		{name: "non-numeric version", files: []string{"v1_create_users.up.sql"}, wantErr: "invalid migration version"},
+		{name: "duplicate version", files: []string{"0001_create_users.up.sql", "0001_create_items.up.sql"}, wantErr: "duplicate migration version 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["migrations/test/"+name] = &fstest.MapFile{Data: []byte("-- " + name)}
+			}
+
+			migrations, err := parseMigrations(fsys, "migrations/test")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			var gotDown []bool
+			for _, m := range migrations {
+				got = append(got, fmt.Sprintf("%d_%s", m.Version, m.Name))
+				gotDown = append(gotDown, m.Down != "")
+			}
+			if !slices.Equal(got, tt.want) || !slices.Equal(gotDown, tt.wantDown) {
+				t.Errorf("migrations = %v (down %v), want %v (down %v)", got, gotDown, tt.want, tt.wantDown)
+			}
+		})
+	}
+}
+
+// 每種資料庫的遷移版本必須一致
+func TestMigrationsMatchAcrossDialects(t *testing.T) {
+	versions := func(dialect Dialect) []string {
+		migrations, err := Migrations(dialect)
+		if err != nil {
+			t.Fatalf("Migrations(%s): %v", dialect, err)
+		}
+		var list []string
+		for _, m := range migrations {
+			list = append(list, fmt.Sprintf("%d_%s", m.Version, m.Name))
+			if m.Down == "" {
+				t.Errorf("%s: migration %d_%s has no down file", dialect, m.Version, m.Name)
+			}
+		}
+		return list
+	}
+
+	want := versions(MySQL)
+	for _, dialect := range []Dialect{Postgres, SQLite} {
+		if got := versions(dialect); !slices.Equal(got, want) {
+			t.Errorf("%s migrations = %v, want %v", dialect, got, want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+-- 角色與角色權限，新註冊的用戶使用預設角色 87
+CREATE TABLE roles (
+    id          INT          NOT NULL PRIMARY KEY,
+    name        VARCHAR(50)  NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_id    INT         NOT NULL,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles (id) ON DELETE CASCADE
+);
+
+INSERT INTO roles (id, name, description) VALUES
+    (1, 'admin', 'Administrator'),
+    (87, 'user', 'Registered user');
+
+INSERT INTO role_permissions (role_id, permission) VALUES
+    (1, 'admin'),
+    (1, 'items:read'),
+    (1, 'items:write'),
+    (1, 'profile:write'),
+    (87, 'items:read'),
+    (87, 'items:write'),
+    (87, 'profile:write');
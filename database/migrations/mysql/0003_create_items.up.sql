@@ -0,0 +1,5 @@
+CREATE TABLE items (
+    id    INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    value VARCHAR(255) NOT NULL,
+    INDEX idx_items_value (value)
+);
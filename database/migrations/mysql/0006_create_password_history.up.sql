@@ -0,0 +1,9 @@
+-- 用戶使用過的密碼雜湊，用於禁止重複使用舊密碼（password.history）
+CREATE TABLE password_history (
+    id            INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id       INT          NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NOT NULL,
+    INDEX idx_password_history_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+-- config 表的修改紀錄，透過管理 API 新增、修改、刪除配置時寫入
+CREATE TABLE config_audit (
+    id         INT          NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    config_key VARCHAR(100) NOT NULL,
+    action     VARCHAR(16)  NOT NULL,
+    old_value  TEXT         NULL,
+    new_value  TEXT         NULL,
+    changed_by VARCHAR(32)  NOT NULL,
+    changed_at DATETIME     NOT NULL,
+    INDEX idx_config_audit_key (config_key, changed_at)
+);
@@ -0,0 +1,5 @@
+-- 執行期配置，例如密碼規則與登入限制（password.*、login.*）
+CREATE TABLE config (
+    "key" VARCHAR(100) NOT NULL PRIMARY KEY,
+    value TEXT         NOT NULL
+);
@@ -0,0 +1,12 @@
+-- JWT 的撤銷：token_version 增加後，之前簽發的 Access Token 與 Refresh Token 全部失效
+ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0;
+
+-- 尚未使用的 Refresh Token，每次刷新時刪除使用過的一筆並新增新的一筆
+CREATE TABLE refresh_tokens (
+    jti        VARCHAR(64) NOT NULL PRIMARY KEY,
+    user_id    INT         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens (user_id, expires_at);
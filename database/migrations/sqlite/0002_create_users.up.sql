@@ -0,0 +1,18 @@
+-- 用戶帳號與用戶資訊，兩者以 username 對應
+CREATE TABLE users (
+    id            INTEGER      PRIMARY KEY AUTOINCREMENT,
+    username      VARCHAR(32)  NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role_id       INT          NOT NULL REFERENCES roles (id)
+);
+
+CREATE TABLE profiles (
+    user_id   INT          NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    username  VARCHAR(32)  NOT NULL UNIQUE,
+    nickname  VARCHAR(32)  NOT NULL DEFAULT '',
+    firstname VARCHAR(50)  NOT NULL DEFAULT '',
+    lastname  VARCHAR(50)  NOT NULL DEFAULT '',
+    email     VARCHAR(254) NOT NULL DEFAULT '',
+    gender    VARCHAR(16)  NOT NULL DEFAULT '',
+    birthday  DATE         NULL
+);
@@ -0,0 +1,6 @@
+CREATE TABLE items (
+    id    INTEGER      PRIMARY KEY AUTOINCREMENT,
+    value VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX idx_items_value ON items (value);
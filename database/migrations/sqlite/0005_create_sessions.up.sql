@@ -0,0 +1,13 @@
+-- 伺服器端 Session，SESSION_STORE=sql 時使用
+CREATE TABLE sessions (
+    id         VARCHAR(64) NOT NULL PRIMARY KEY,
+    username   VARCHAR(32) NOT NULL DEFAULT '',
+    data       BLOB        NOT NULL,
+    user_agent TEXT        NOT NULL,
+    ip         VARCHAR(45) NOT NULL DEFAULT '',
+    created_at DATETIME    NOT NULL,
+    expires_at DATETIME    NOT NULL
+);
+
+CREATE INDEX idx_sessions_username ON sessions (username);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
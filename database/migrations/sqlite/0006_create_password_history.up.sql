@@ -0,0 +1,9 @@
+-- 用戶使用過的密碼雜湊，用於禁止重複使用舊密碼（password.history）
+CREATE TABLE password_history (
+    id            INTEGER      PRIMARY KEY AUTOINCREMENT,
+    user_id       INT          NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NOT NULL
+);
+
+CREATE INDEX idx_password_history_user ON password_history (user_id, created_at);
@@ -0,0 +1,119 @@
+// Package logging 使用 log/slog 輸出結構化日誌，並為每個請求指定 Request ID，
+// 處理函數透過 FromContext 取得帶有 Request ID 的 Logger。
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"http-server/recorder"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader 是傳遞 Request ID 的標頭，反向代理已設定時沿用，否則由伺服器產生
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 是接受的 Request ID 最大長度，超過或包含其他字元時改為自行產生
+const maxRequestIDLength = 128
+
+// New 建立 Logger，level 為 debug、info、warn 或 error，format 為 text 或 json
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+}
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+)
+
+// FromContext 返回 ctx 中的 Logger，沒有時返回 slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithLogger 返回保存 logger 的 ctx
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// RequestID 返回 ctx 中的 Request ID，不在請求中時為空字串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Middleware 為每個請求指定 Request ID，寫入響應標頭，並在 context 中保存帶有 request_id 的 Logger
+// 請求結束時記錄方法、路由、狀態碼與耗時
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logger := slog.Default().With("request_id", id)
+		ctx := context.WithValue(WithLogger(r.Context(), logger), requestIDKey, id)
+		r = r.WithContext(ctx)
+
+		start := time.Now()
+		rec := recorder.Wrap(w)
+		next.ServeHTTP(rec, r)
+
+		// r.Pattern 由 ServeMux 在分派請求時設定
+		logger.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", r.Pattern,
+			"status", rec.Status(),
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+// validRequestID 只接受長度合理、由英數字與 -_.: 組成的 Request ID，避免日誌注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 產生 16 bytes 的隨機 Request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
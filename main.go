@@ -1,20 +1,221 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"fmt"
+	"http-server/app"
+	"http-server/certs"
 	"http-server/config"
+	"http-server/logging"
 	"http-server/routes" // 匯入路由設定
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	// 初始化全局配置
-	config.InitConfig()
+	// 依照 預設值 < 設定檔 < .env < 環境變數 < 命令列參數 的順序載入設定
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Printf("設定載入失敗: %v\n", err)
+		os.Exit(2)
+	}
 
-	// 註冊路由
-	routes.Routes()
+	// 之後的日誌都透過 slog 輸出，格式與等級由 LOG_FORMAT、LOG_LEVEL 決定
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fmt.Printf("設定載入失敗: %v\n", err)
+		os.Exit(2)
+	}
+	slog.SetDefault(logger)
 
-	// 啟動伺服器，監聽在 8080 埠號
-	fmt.Println("伺服器啟動，監聽在 http://localhost:8080")
-	http.ListenAndServe(":8080", nil)
-}
\ No newline at end of file
+	// 子命令只執行對應的工作，不啟動伺服器
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, args[1:]))
+		case "config":
+			os.Exit(runConfig(cfg, args[1:]))
+		default:
+			fmt.Printf("未知的命令: %s\n", args[0])
+			os.Exit(2)
+		}
+	}
+
+	// 建立資料庫連線、Session Store 與配置
+	a, err := app.New(cfg)
+	if err != nil {
+		slog.Error("初始化失敗", "err", err)
+		os.Exit(1)
+	}
+
+	// 依照設定建立 HTTP Server
+	serverConfig := cfg.Server
+	server := &http.Server{
+		Addr:              serverConfig.Addr,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+		MaxHeaderBytes:    serverConfig.MaxHeaderBytes,
+		Handler:           routes.Routes(a),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	// 收到 SIGINT 或 SIGTERM 時開始關閉伺服器
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 收到 SIGHUP 或每隔 CONFIG_RELOAD_INTERVAL 重新載入 config 表
+	go a.Config.Watch(ctx, cfg.ConfigReloadInterval)
+
+	// 直接提供 HTTPS 時，載入憑證並監看檔案更新
+	var redirectServer *http.Server
+	if serverConfig.TLSEnabled() {
+		tlsConfig, err := setupTLS(ctx, serverConfig)
+		if err != nil {
+			slog.Error("TLS 設定失敗", "err", err)
+			closeResources(a)
+			os.Exit(1)
+		}
+		server.TLSConfig = tlsConfig
+
+		if serverConfig.TLSRedirectAddr != "" {
+			redirectServer = &http.Server{
+				Addr:              serverConfig.TLSRedirectAddr,
+				Handler:           redirectToHTTPS(serverConfig.Addr),
+				ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+				IdleTimeout:       serverConfig.IdleTimeout,
+				ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+			}
+		}
+	}
+
+	// 設定 METRICS_ADDR 時另外監聽，提供 Prometheus 抓取不需要登入的 /metrics
+	// 這個位址應只開放給內部網路
+	var metricsServer *http.Server
+	if serverConfig.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", a.Metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:              serverConfig.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+			IdleTimeout:       serverConfig.IdleTimeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+	}
+
+	// 啟動伺服器
+	serverErr := make(chan error, 3)
+	go func() {
+		if serverConfig.TLSEnabled() {
+			slog.Info("伺服器啟動", "url", "https://"+serverConfig.Addr)
+			serverErr <- server.ListenAndServeTLS("", "")
+			return
+		}
+		slog.Info("伺服器啟動", "url", "http://"+serverConfig.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+	if redirectServer != nil {
+		go func() {
+			slog.Info("HTTP 重定向啟動", "addr", redirectServer.Addr)
+			serverErr <- redirectServer.ListenAndServe()
+		}()
+	}
+	if metricsServer != nil {
+		go func() {
+			slog.Info("Metrics 啟動", "url", "http://"+metricsServer.Addr+"/metrics")
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("伺服器啟動失敗", "err", err)
+			closeResources(a)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		stop() // 再次收到信號時直接結束程序
+		slog.Info("收到關閉信號，等待進行中的請求完成", "timeout", serverConfig.ShutdownTimeout)
+	}
+
+	// 停止接受新連線，並在期限內等待進行中的請求完成
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("伺服器未能在期限內關閉", "err", err)
+		server.Close()
+	}
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(shutdownCtx); err != nil {
+			redirectServer.Close()
+		}
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			metricsServer.Close()
+		}
+	}
+
+	closeResources(a)
+	slog.Info("伺服器已關閉")
+}
+
+// setupTLS 建立 TLS 設定，憑證透過 Reloader 提供，檔案更新後自動生效
+// 啟用 TLS_SELF_SIGNED 且憑證不存在時，會先產生自簽憑證
+func setupTLS(ctx context.Context, serverConfig config.ServerConfig) (*tls.Config, error) {
+	if serverConfig.TLSSelfSigned {
+		if _, err := os.Stat(serverConfig.TLSCertFile); os.IsNotExist(err) {
+			slog.Info("產生自簽憑證", "cert", serverConfig.TLSCertFile)
+			err := certs.GenerateSelfSigned(serverConfig.TLSCertFile, serverConfig.TLSKeyFile, serverConfig.TLSSelfSignedHosts)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	reloader, err := certs.NewReloader(serverConfig.TLSCertFile, serverConfig.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Watch(ctx, serverConfig.TLSReloadInterval)
+
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+// redirectToHTTPS 將 HTTP 請求重定向到 httpsAddr 所監聽的 HTTPS 埠號
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// closeResources 關閉 Session Store 與資料庫連線池
+func closeResources(a *app.App) {
+	if err := a.Close(); err != nil {
+		slog.Error("資源關閉失敗", "err", err)
+	}
+}
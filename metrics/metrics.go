@@ -0,0 +1,136 @@
+// Package metrics 收集 HTTP 請求、資料庫連線池與登入的統計，並以 Prometheus 的文字格式提供給 /metrics。
+package metrics
+
+import (
+	"database/sql"
+	"http-server/database"
+	"http-server/recorder"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 登入結果，作為 auth_login_attempts_total 的 result 標籤
+const (
+	LoginSuccess = "success" // 登入成功
+	LoginFailure = "failure" // 用戶名或密碼錯誤
+	LoginLocked  = "locked"  // 失敗太多次而被暫時拒絕
+	LoginError   = "error"   // 伺服器錯誤
+)
+
+// unmatchedRoute 是沒有對應路由（例如方法不允許）的請求使用的 route 標籤
+const unmatchedRoute = "unmatched"
+
+// otherMethod 是非標準 HTTP 方法使用的 method 標籤
+const otherMethod = "OTHER"
+
+// knownMethods 是 method 標籤允許的值，客戶端可以送出任意方法，直接使用會讓序列無限增加
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Metrics 保存所有指標，使用獨立的 Registry 而不是 prometheus 的全局變數
+type Metrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	logins       *prometheus.CounterVec
+}
+
+// New 建立並註冊所有指標，db 不為 nil 時一併收集連線池的統計
+func New(db *sql.DB) *Metrics {
+	labels := []string{"route", "method", "status"}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size in bytes.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+		}, labels),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_login_attempts_total",
+			Help: "Total number of login attempts by result.",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.responseSize,
+		m.logins,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if db != nil {
+		// go_sql_* 指標：連線數、使用中、閒置、等待次數與時間
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, string(database.DialectOf(db))))
+	}
+
+	// 先建立登入結果的序列，讓沒有發生過的結果也顯示為 0
+	for _, result := range []string{LoginSuccess, LoginFailure, LoginLocked, LoginError} {
+		m.logins.WithLabelValues(result)
+	}
+	return m
+}
+
+// Handler 返回以 Prometheus 文字格式輸出所有指標的處理函數
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// LoginAttempt 記錄一次登入的結果
+func (m *Metrics) LoginAttempt(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// Middleware 記錄每個請求的次數、耗時與響應大小
+// route 標籤使用 ServeMux 匹配到的路由（例如 /api/items/{id}），避免每個 ID 產生一組序列
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := recorder.Wrap(w)
+		next.ServeHTTP(rec, r)
+
+		// ServeMux 在分派請求時設定 r.Pattern，格式為 [方法 ]路徑
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		method := r.Method
+		if !knownMethods[method] {
+			method = otherMethod
+		}
+
+		labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(rec.Status())}
+		m.requests.With(labels).Inc()
+		m.duration.With(labels).Observe(time.Since(start).Seconds())
+		m.responseSize.With(labels).Observe(float64(rec.Bytes()))
+	})
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// scrape 返回 /metrics 的文字輸出
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestMiddlewareLabels(t *testing.T) {
+	m := New(nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	h := m.Middleware(mux)
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/api/items/1"},
+		{http.MethodGet, "/api/items/2"},
+		{"FOOBAR", "/"},
+		{"BLAHBLAH", "/anything"},
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		// 同一路由的不同 ID 合併為一組序列
+		`http_requests_total{method="GET",route="/api/items/{id}",status="200"} 2`,
+		// 非標準方法合併為 OTHER
+		`http_requests_total{method="OTHER",route="/",status="404"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+	for _, unwanted := range []string{"FOOBAR", "BLAHBLAH", "/api/items/1"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("metrics output contains %q", unwanted)
+		}
+	}
+}
+
+func TestLoginAttempt(t *testing.T) {
+	m := New(nil)
+	m.LoginAttempt(LoginSuccess)
+	m.LoginAttempt(LoginFailure)
+	m.LoginAttempt(LoginFailure)
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`auth_login_attempts_total{result="success"} 1`,
+		`auth_login_attempts_total{result="failure"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
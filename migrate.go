@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"http-server/config"
+	"http-server/database"
+	"strconv"
+)
+
+const migrateUsage = `用法: http-server migrate <up|down [N]|status>
+
+  up        套用所有尚未套用的遷移
+  down [N]  撤銷最近 N 個已套用的遷移（預設 1）
+  status    列出所有遷移與套用時間`
+
+// runMigrate 執行 migrate 子命令，返回程序的結束碼
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			fmt.Println(migrateUsage)
+			return 2
+		}
+	case "down":
+		if len(args) > 2 {
+			fmt.Println(migrateUsage)
+			return 2
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Printf("無效的步數: %s\n", args[1])
+				return 2
+			}
+			steps = n
+		}
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	// 在 docker-compose 中與資料庫同時啟動時，同樣等待資料庫就緒
+	opts := cfg.Database.Options()
+	opts.AutoMigrate = false
+	db, err := database.InitDB(cfg.Database.DSN, opts)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, m := range applied {
+			fmt.Printf("已套用 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("沒有需要套用的遷移")
+		}
+	case "down":
+		reverted, err := database.MigrateDown(db, steps)
+		for _, m := range reverted {
+			fmt.Printf("已撤銷 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("沒有可以撤銷的遷移")
+		}
+	case "status":
+		status, err := database.GetMigrationStatus(db)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		for _, s := range status {
+			applied := "尚未套用"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"http-server/database"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// DefaultRoleID 是新註冊用戶的預設角色
+const DefaultRoleID = "87"
+
+// Registration 是註冊新用戶所需的資料
+type Registration struct {
+	Username     string
+	PasswordHash string
+	RoleID       string
+	Nickname     string
+	Firstname    string
+	Lastname     string
+	Email        string
+	Gender       string
+	Birthday     *time.Time
+}
+
+// SQLAccountRepository 透過 SQL 處理需要同時修改多個資料表的帳號操作
+type SQLAccountRepository struct {
+	db *sql.DB
+}
+
+// NewSQLAccountRepository 建立使用 db 的 Repository
+func NewSQLAccountRepository(db *sql.DB) *SQLAccountRepository {
+	return &SQLAccountRepository{db: db}
+}
+
+// RegisterUser 在同一個交易中新增用戶、指定角色並建立用戶資訊，任何一步失敗都不會留下資料
+// 用戶名已存在時返回 ErrDuplicate
+func (repo *SQLAccountRepository) RegisterUser(reg Registration) error {
+	return database.WithTx(repo.db, func(tx *sql.Tx) error {
+		query := "INSERT INTO users (username, password_hash, role_id) VALUES (?, ?, ?)"
+		userID, err := database.InsertID(database.DialectOf(repo.db), tx, query, reg.Username, reg.PasswordHash, reg.RoleID)
+		if err != nil {
+			return duplicateKeyError(err)
+		}
+
+		query = "INSERT INTO profiles (user_id, username, nickname, firstname, lastname, email, gender, birthday) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+		_, err = tx.Exec(query, userID, reg.Username, reg.Nickname, reg.Firstname, reg.Lastname, reg.Email, reg.Gender, reg.Birthday)
+		return duplicateKeyError(err)
+	})
+}
+
+// 違反唯一鍵時的錯誤碼：MySQL 的 ER_DUP_ENTRY 與 PostgreSQL 的 unique_violation
+const (
+	mysqlErrDupEntry       = 1062
+	postgresErrUniqueValue = "23505"
+)
+
+// duplicateKeyError 將各資料庫的唯一鍵衝突轉為 ErrDuplicate，其他錯誤原樣返回
+func duplicateKeyError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry {
+		return fmt.Errorf("%w: %s", ErrDuplicate, mysqlErr.Message)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == postgresErrUniqueValue {
+		return fmt.Errorf("%w: %s", ErrDuplicate, pqErr.Message)
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey) {
+		return fmt.Errorf("%w: %s", ErrDuplicate, sqliteErr.Error())
+	}
+	return err
+}
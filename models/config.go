@@ -1,7 +1,9 @@
 package models
 
 import (
+	"database/sql"
 	"http-server/database"
+	"time"
 )
 
 // ConfigEntry 表示 config 表中的一條記錄
@@ -10,10 +12,38 @@ type ConfigEntry struct {
 	Value string
 }
 
+// 配置修改紀錄的動作
+const (
+	ConfigActionCreate = "create"
+	ConfigActionUpdate = "update"
+	ConfigActionDelete = "delete"
+)
+
+// ConfigAudit 表示 config_audit 表中的一條修改紀錄，新增時 OldValue 為 nil，刪除時 NewValue 為 nil
+type ConfigAudit struct {
+	ID        int       `json:"id"`
+	Key       string    `json:"key"`
+	Action    string    `json:"action"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// SQLConfigRepository 透過 SQL 存取 config 資料表
+type SQLConfigRepository struct {
+	db *sql.DB
+}
+
+// NewSQLConfigRepository 建立使用 db 的 Repository
+func NewSQLConfigRepository(db *sql.DB) *SQLConfigRepository {
+	return &SQLConfigRepository{db: db}
+}
+
 // GetAllConfigs 查詢所有配置
-func GetAllConfigs() ([]ConfigEntry, error) {
+func (repo *SQLConfigRepository) GetAllConfigs() ([]ConfigEntry, error) {
 	query := "SELECT `key`, value FROM config"
-	rows, err := database.DB.Query(query)
+	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
@@ -27,5 +57,88 @@ func GetAllConfigs() ([]ConfigEntry, error) {
 		}
 		configs = append(configs, config)
 	}
-	return configs, nil
-}
\ No newline at end of file
+	return configs, rows.Err()
+}
+
+// GetConfig 根據 key 查詢配置，不存在時返回 sql.ErrNoRows
+func (repo *SQLConfigRepository) GetConfig(key string) (*ConfigEntry, error) {
+	var config ConfigEntry
+	err := repo.db.QueryRow("SELECT `key`, value FROM config WHERE `key` = ?", key).Scan(&config.Key, &config.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// AddConfig 新增配置並記錄修改者，key 已存在時返回 ErrDuplicate
+func (repo *SQLConfigRepository) AddConfig(key, value, changedBy string) error {
+	return database.WithTx(repo.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("INSERT INTO config (`key`, value) VALUES (?, ?)", key, value); err != nil {
+			return duplicateKeyError(err)
+		}
+		return addConfigAudit(tx, key, ConfigActionCreate, nil, &value, changedBy)
+	})
+}
+
+// UpdateConfig 更新配置並記錄修改者與舊值，不存在時返回 sql.ErrNoRows
+func (repo *SQLConfigRepository) UpdateConfig(key, value, changedBy string) error {
+	return database.WithTx(repo.db, func(tx *sql.Tx) error {
+		var old string
+		if err := tx.QueryRow("SELECT value FROM config WHERE `key` = ? FOR UPDATE", key).Scan(&old); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE config SET value = ? WHERE `key` = ?", value, key); err != nil {
+			return err
+		}
+		return addConfigAudit(tx, key, ConfigActionUpdate, &old, &value, changedBy)
+	})
+}
+
+// DeleteConfig 刪除配置並記錄修改者與舊值，不存在時返回 sql.ErrNoRows
+func (repo *SQLConfigRepository) DeleteConfig(key, changedBy string) error {
+	return database.WithTx(repo.db, func(tx *sql.Tx) error {
+		var old string
+		if err := tx.QueryRow("SELECT value FROM config WHERE `key` = ? FOR UPDATE", key).Scan(&old); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM config WHERE `key` = ?", key); err != nil {
+			return err
+		}
+		return addConfigAudit(tx, key, ConfigActionDelete, &old, nil, changedBy)
+	})
+}
+
+// GetConfigAudit 查詢最近 limit 筆修改紀錄，新的在前，key 為空字串時查詢所有 key
+func (repo *SQLConfigRepository) GetConfigAudit(key string, limit int) ([]ConfigAudit, error) {
+	query := "SELECT id, config_key, action, old_value, new_value, changed_by, changed_at FROM config_audit"
+	var args []interface{}
+	if key != "" {
+		query += " WHERE config_key = ?"
+		args = append(args, key)
+	}
+	query += " ORDER BY changed_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := []ConfigAudit{}
+	for rows.Next() {
+		var a ConfigAudit
+		if err := rows.Scan(&a.ID, &a.Key, &a.Action, &a.OldValue, &a.NewValue, &a.ChangedBy, &a.ChangedAt); err != nil {
+			return nil, err
+		}
+		audits = append(audits, a)
+	}
+	return audits, rows.Err()
+}
+
+// addConfigAudit 在交易中寫入一筆修改紀錄
+func addConfigAudit(tx *sql.Tx, key, action string, oldValue, newValue *string, changedBy string) error {
+	query := "INSERT INTO config_audit (config_key, action, old_value, new_value, changed_by, changed_at) VALUES (?, ?, ?, ?, ?, ?)"
+	_, err := tx.Exec(query, key, action, oldValue, newValue, changedBy, time.Now())
+	return err
+}
@@ -1,53 +1,193 @@
 package models
 
 import (
+	"database/sql"
+	"fmt"
 	"http-server/database"
+	"strings"
 )
 
 // Item 是用來表示 items 資料表中的一個資料結構
 type Item struct {
 	ID    int    `json:"id"`
-	Value string `json:"value"`
+	Value string `json:"value" validate:"required,max=255"`
 }
 
-// GetAllItems 查詢所有 items
-func GetAllItems() ([]Item, error) {
-	// 從資料庫中查詢所有資料
-	rows, err := database.DB.Query("SELECT id, value FROM items")
+// ItemQuery 是分頁查詢 items 的條件
+// 使用 After 時為游標（keyset）分頁，否則使用 Offset 分頁
+type ItemQuery struct {
+	Limit  int         // 每頁筆數
+	Offset int         // 略過的筆數，After 不為 nil 時忽略
+	After  *ItemCursor // 從此游標之後開始查詢
+	Sort   string      // 排序方式：id、-id、value、-value，前綴 - 表示降序
+	Search string      // value 的子字串篩選，空字串表示不篩選
+}
+
+// ItemCursor 記錄上一頁最後一筆的排序鍵，用於游標分頁
+type ItemCursor struct {
+	ID    int
+	Value string
+}
+
+// itemSorts 是允許的排序方式，對應到 ORDER BY 子句
+var itemSorts = map[string]string{
+	"id":     "id ASC",
+	"-id":    "id DESC",
+	"value":  "value ASC, id ASC",
+	"-value": "value DESC, id DESC",
+}
+
+// ValidItemSort 判斷排序方式是否合法
+func ValidItemSort(sort string) bool {
+	_, ok := itemSorts[sort]
+	return ok
+}
+
+// SQLItemRepository 透過 SQL 存取 items 資料表
+type SQLItemRepository struct {
+	db *sql.DB
+}
+
+// NewSQLItemRepository 建立使用 db 的 Repository
+func NewSQLItemRepository(db *sql.DB) *SQLItemRepository {
+	return &SQLItemRepository{db: db}
+}
+
+// QueryItems 依照條件分頁查詢 items
+// 返回符合條件的 items、篩選後的總筆數，以及之後是否還有資料
+func (repo *SQLItemRepository) QueryItems(q ItemQuery) ([]Item, int, bool, error) {
+	orderBy, ok := itemSorts[q.Sort]
+	if !ok {
+		return nil, 0, false, fmt.Errorf("invalid sort: %s", q.Sort)
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Search != "" {
+		// LIKE 在 PostgreSQL 區分大小寫，統一轉成小寫比對，讓各資料庫與記憶體版本的結果一致
+		where = append(where, "LOWER(value) LIKE LOWER(?) ESCAPE '!'")
+		args = append(args, "%"+escapeLike(q.Search)+"%")
+	}
+
+	// 計算篩選後的總筆數（不受游標影響）
+	countQuery := "SELECT COUNT(*) FROM items"
+	if len(where) > 0 {
+		countQuery += " WHERE " + strings.Join(where, " AND ")
+	}
+	var total int
+	if err := repo.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, false, err
+	}
+
+	if q.After != nil {
+		switch q.Sort {
+		case "id":
+			where = append(where, "id > ?")
+			args = append(args, q.After.ID)
+		case "-id":
+			where = append(where, "id < ?")
+			args = append(args, q.After.ID)
+		case "value":
+			where = append(where, "(value > ? OR (value = ? AND id > ?))")
+			args = append(args, q.After.Value, q.After.Value, q.After.ID)
+		case "-value":
+			where = append(where, "(value < ? OR (value = ? AND id < ?))")
+			args = append(args, q.After.Value, q.After.Value, q.After.ID)
+		}
+	}
+
+	query := "SELECT id, value FROM items"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	// 多查一筆，用來判斷是否還有下一頁
+	query += " ORDER BY " + orderBy + " LIMIT ?"
+	args = append(args, q.Limit+1)
+	if q.After == nil && q.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, q.Offset)
+	}
+
+	rows, err := repo.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, false, err
 	}
-	defer rows.Close() // 確保查詢結果的資源在使用完後被正確釋放
+	defer rows.Close()
 
-	var items []Item
-	// 遍歷查詢結果，將每一行映射到 Item 結構，並添加到 items 列表中
+	items := []Item{}
 	for rows.Next() {
 		var item Item
 		if err := rows.Scan(&item.ID, &item.Value); err != nil {
-			return nil, err
+			return nil, 0, false, err
 		}
 		items = append(items, item)
 	}
-	return items, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, false, err
+	}
+
+	hasMore := len(items) > q.Limit
+	if hasMore {
+		items = items[:q.Limit]
+	}
+	return items, total, hasMore, nil
+}
+
+// escapeLike 跳脫 LIKE 的萬用字元，搭配 ESCAPE '!' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// GetItemByID 根據 ID 查詢 item，不存在時返回 sql.ErrNoRows
+func (repo *SQLItemRepository) GetItemByID(id int) (*Item, error) {
+	row := repo.db.QueryRow("SELECT id, value FROM items WHERE id = ?", id)
+
+	var item Item
+	if err := row.Scan(&item.ID, &item.Value); err != nil {
+		return nil, err
+	}
+	return &item, nil
 }
 
-// AddItem 新增一個 item
-func AddItem(value string) error {
+// AddItem 新增一個 item，返回新 item 的 ID
+func (repo *SQLItemRepository) AddItem(value string) (int, error) {
 	query := "INSERT INTO items (value) VALUES (?)"
-	_, err := database.DB.Exec(query, value)
-	return err
+	id, err := database.InsertID(database.DialectOf(repo.db), repo.db, query, value)
+	return int(id), err
 }
 
-// DeleteItem 根據 ID 刪除 item
-func DeleteItem(id string) error {
+// DeleteItem 根據 ID 刪除 item，不存在時返回 sql.ErrNoRows
+func (repo *SQLItemRepository) DeleteItem(id int) error {
 	query := "DELETE FROM items WHERE id = ?"
-	_, err := database.DB.Exec(query, id)
-	return err
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-// UpdateItem 根據 ID 更新 item
-func UpdateItem(id, value string) error {
+// UpdateItem 根據 ID 更新 item，不存在時返回 sql.ErrNoRows
+func (repo *SQLItemRepository) UpdateItem(id int, value string) error {
 	query := "UPDATE items SET value = ? WHERE id = ?"
-	_, err := database.DB.Exec(query, value, id)
-	return err
-}
\ No newline at end of file
+	result, err := repo.db.Exec(query, value, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// MySQL 在值未改變時 RowsAffected 也是 0，需要再確認資料是否存在
+		_, err := repo.GetItemByID(id)
+		return err
+	}
+	return nil
+}
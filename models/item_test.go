@@ -0,0 +1,64 @@
+package models
+
+import (
+	"http-server/database"
+	"slices"
+	"testing"
+)
+
+// newSQLiteItemRepository 建立使用 SQLite 記憶體資料庫（已套用所有遷移）的 SQLItemRepository
+// :memory: 的資料只存在於單一連線中，因此需要保留閒置連線
+func newSQLiteItemRepository(t *testing.T) *SQLItemRepository {
+	t.Helper()
+	db, err := database.InitDB("sqlite://:memory:", database.Options{MaxIdleConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	return NewSQLItemRepository(db)
+}
+
+// 搜尋在 SQL 與記憶體版本中都不分大小寫，並將 % 與 _ 當作一般字元
+func TestQueryItemsSearch(t *testing.T) {
+	repos := map[string]ItemRepository{
+		"sql":    newSQLiteItemRepository(t),
+		"memory": NewMemoryItemRepository(),
+	}
+	values := []string{"Alpha", "alphabet", "ALPS", "beta", "100%", "a_b"}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{search: "alp", want: []string{"Alpha", "alphabet", "ALPS"}},
+		{search: "ALPHA", want: []string{"Alpha", "alphabet"}},
+		{search: "%", want: []string{"100%"}},
+		{search: "_", want: []string{"a_b"}},
+		{search: "", want: values},
+	}
+	for name, repo := range repos {
+		for _, value := range values {
+			if _, err := repo.AddItem(value); err != nil {
+				t.Fatalf("%s AddItem: %v", name, err)
+			}
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.search, func(t *testing.T) {
+				items, total, _, err := repo.QueryItems(ItemQuery{Limit: 10, Sort: "id", Search: tt.search})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, item := range items {
+					got = append(got, item.Value)
+				}
+				if !slices.Equal(got, tt.want) || total != len(tt.want) {
+					t.Errorf("QueryItems(%q) = %v (total %d), want %v", tt.search, got, total, tt.want)
+				}
+			})
+		}
+	}
+}
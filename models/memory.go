@@ -0,0 +1,478 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserRepository 將用戶保存在記憶體中
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[string]User
+	nextID int
+}
+
+// NewMemoryUserRepository 建立空的記憶體 Repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]User), nextID: 1}
+}
+
+// AddUser 新增用戶，用戶名已存在時返回 ErrDuplicate
+func (repo *MemoryUserRepository) AddUser(username, passwordHash, roleID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.users[username]; ok {
+		return ErrDuplicate
+	}
+	repo.users[username] = User{ID: repo.nextID, Username: username, PasswordHash: passwordHash, RoleID: roleID}
+	repo.nextID++
+	return nil
+}
+
+// GetUserByUsername 根據用戶名查詢用戶
+func (repo *MemoryUserRepository) GetUserByUsername(username string) (*User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	user, ok := repo.users[username]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &user, nil
+}
+
+// ChangePasswordByUsername 根據用戶名更新密碼，並增加 TokenVersion
+func (repo *MemoryUserRepository) ChangePasswordByUsername(username, passwordHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if user, ok := repo.users[username]; ok {
+		user.PasswordHash = passwordHash
+		user.TokenVersion++
+		repo.users[username] = user
+	}
+	return nil
+}
+
+// remove 刪除用戶，用於回滾註冊
+func (repo *MemoryUserRepository) remove(username string) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	delete(repo.users, username)
+}
+
+// MemoryProfileRepository 將用戶資訊保存在記憶體中
+type MemoryProfileRepository struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+	users    UserRepository
+}
+
+// NewMemoryProfileRepository 建立空的記憶體 Repository，users 用來填入 Profile.UserID
+func NewMemoryProfileRepository(users UserRepository) *MemoryProfileRepository {
+	return &MemoryProfileRepository{profiles: make(map[string]Profile), users: users}
+}
+
+// AddProfile 新增用戶資訊，已存在時返回 ErrDuplicate
+func (repo *MemoryProfileRepository) AddProfile(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
+	var userID int
+	if user, err := repo.users.GetUserByUsername(username); err == nil {
+		userID = user.ID
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.profiles[username]; ok {
+		return ErrDuplicate
+	}
+	repo.profiles[username] = Profile{
+		UserID:    userID,
+		Username:  username,
+		Nickname:  nickname,
+		Firstname: firstname,
+		Lastname:  lastname,
+		Email:     email,
+		Gender:    gender,
+		Birthday:  birthday,
+	}
+	return nil
+}
+
+// GetProfileByUsername 根據用戶名查詢用戶資訊
+func (repo *MemoryProfileRepository) GetProfileByUsername(username string) (*Profile, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	profile, ok := repo.profiles[username]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &profile, nil
+}
+
+// UpdateProfileByUsername 根據用戶名更新用戶資訊
+func (repo *MemoryProfileRepository) UpdateProfileByUsername(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	profile, ok := repo.profiles[username]
+	if !ok {
+		return nil
+	}
+	profile.Nickname = nickname
+	profile.Firstname = firstname
+	profile.Lastname = lastname
+	profile.Email = email
+	profile.Gender = gender
+	profile.Birthday = birthday
+	repo.profiles[username] = profile
+	return nil
+}
+
+// MemoryRoleRepository 將角色保存在記憶體中，建立後不可修改
+type MemoryRoleRepository struct {
+	roles map[string]Role
+}
+
+// NewMemoryRoleRepository 建立包含 roles 的記憶體 Repository
+func NewMemoryRoleRepository(roles ...Role) *MemoryRoleRepository {
+	repo := &MemoryRoleRepository{roles: make(map[string]Role, len(roles))}
+	for _, role := range roles {
+		repo.roles[strconv.Itoa(role.ID)] = role
+	}
+	return repo
+}
+
+// GetRoleById 查詢角色與其權限
+func (repo *MemoryRoleRepository) GetRoleById(id string) (*Role, error) {
+	role, ok := repo.roles[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	role.Permissions = append([]string(nil), role.Permissions...)
+	return &role, nil
+}
+
+// MemoryItemRepository 將 items 保存在記憶體中
+type MemoryItemRepository struct {
+	mu     sync.RWMutex
+	items  map[int]Item
+	nextID int
+}
+
+// NewMemoryItemRepository 建立空的記憶體 Repository
+func NewMemoryItemRepository() *MemoryItemRepository {
+	return &MemoryItemRepository{items: make(map[int]Item), nextID: 1}
+}
+
+// QueryItems 依照條件分頁查詢 items，行為與 SQLItemRepository.QueryItems 相同
+// 搜尋不分大小寫，與 SQL 版本的 LOWER(value) LIKE LOWER(?) 一致
+func (repo *MemoryItemRepository) QueryItems(q ItemQuery) ([]Item, int, bool, error) {
+	if !ValidItemSort(q.Sort) {
+		return nil, 0, false, fmt.Errorf("invalid sort: %s", q.Sort)
+	}
+
+	repo.mu.RLock()
+	matched := make([]Item, 0, len(repo.items))
+	search := strings.ToLower(q.Search)
+	for _, item := range repo.items {
+		if search == "" || strings.Contains(strings.ToLower(item.Value), search) {
+			matched = append(matched, item)
+		}
+	}
+	repo.mu.RUnlock()
+
+	less := func(a, b Item) bool {
+		switch q.Sort {
+		case "-id":
+			return a.ID > b.ID
+		case "value":
+			return a.Value < b.Value || a.Value == b.Value && a.ID < b.ID
+		case "-value":
+			return a.Value > b.Value || a.Value == b.Value && a.ID > b.ID
+		default:
+			return a.ID < b.ID
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+	total := len(matched)
+
+	start := 0
+	if q.After != nil {
+		cursor := Item{ID: q.After.ID, Value: q.After.Value}
+		start = sort.Search(len(matched), func(i int) bool { return less(cursor, matched[i]) })
+	} else if q.Offset > 0 {
+		start = min(q.Offset, len(matched))
+	}
+	end := min(start+q.Limit, len(matched))
+
+	items := append([]Item{}, matched[start:end]...)
+	return items, total, end < len(matched), nil
+}
+
+// GetItemByID 根據 ID 查詢 item，不存在時返回 sql.ErrNoRows
+func (repo *MemoryItemRepository) GetItemByID(id int) (*Item, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	item, ok := repo.items[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &item, nil
+}
+
+// AddItem 新增一個 item，返回新 item 的 ID
+func (repo *MemoryItemRepository) AddItem(value string) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	id := repo.nextID
+	repo.items[id] = Item{ID: id, Value: value}
+	repo.nextID++
+	return id, nil
+}
+
+// DeleteItem 根據 ID 刪除 item，不存在時返回 sql.ErrNoRows
+func (repo *MemoryItemRepository) DeleteItem(id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.items[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(repo.items, id)
+	return nil
+}
+
+// UpdateItem 根據 ID 更新 item，不存在時返回 sql.ErrNoRows
+func (repo *MemoryItemRepository) UpdateItem(id int, value string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.items[id]; !ok {
+		return sql.ErrNoRows
+	}
+	repo.items[id] = Item{ID: id, Value: value}
+	return nil
+}
+
+// MemoryConfigRepository 將配置與修改紀錄保存在記憶體中
+type MemoryConfigRepository struct {
+	mu      sync.RWMutex
+	configs map[string]string
+	audits  []ConfigAudit
+}
+
+// NewMemoryConfigRepository 建立包含 configs 的記憶體 Repository
+func NewMemoryConfigRepository(configs map[string]string) *MemoryConfigRepository {
+	repo := &MemoryConfigRepository{configs: make(map[string]string, len(configs))}
+	for key, value := range configs {
+		repo.configs[key] = value
+	}
+	return repo
+}
+
+// GetAllConfigs 查詢所有配置，依 key 排序
+func (repo *MemoryConfigRepository) GetAllConfigs() ([]ConfigEntry, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	configs := make([]ConfigEntry, 0, len(repo.configs))
+	for key, value := range repo.configs {
+		configs = append(configs, ConfigEntry{Key: key, Value: value})
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Key < configs[j].Key })
+	return configs, nil
+}
+
+// GetConfig 根據 key 查詢配置，不存在時返回 sql.ErrNoRows
+func (repo *MemoryConfigRepository) GetConfig(key string) (*ConfigEntry, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	value, ok := repo.configs[key]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &ConfigEntry{Key: key, Value: value}, nil
+}
+
+// AddConfig 新增配置並記錄修改者，key 已存在時返回 ErrDuplicate
+func (repo *MemoryConfigRepository) AddConfig(key, value, changedBy string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.configs[key]; ok {
+		return ErrDuplicate
+	}
+	repo.configs[key] = value
+	repo.addAudit(key, ConfigActionCreate, nil, &value, changedBy)
+	return nil
+}
+
+// UpdateConfig 更新配置並記錄修改者與舊值，不存在時返回 sql.ErrNoRows
+func (repo *MemoryConfigRepository) UpdateConfig(key, value, changedBy string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	old, ok := repo.configs[key]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	repo.configs[key] = value
+	repo.addAudit(key, ConfigActionUpdate, &old, &value, changedBy)
+	return nil
+}
+
+// DeleteConfig 刪除配置並記錄修改者與舊值，不存在時返回 sql.ErrNoRows
+func (repo *MemoryConfigRepository) DeleteConfig(key, changedBy string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	old, ok := repo.configs[key]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	delete(repo.configs, key)
+	repo.addAudit(key, ConfigActionDelete, &old, nil, changedBy)
+	return nil
+}
+
+// GetConfigAudit 查詢最近 limit 筆修改紀錄，新的在前，key 為空字串時查詢所有 key
+func (repo *MemoryConfigRepository) GetConfigAudit(key string, limit int) ([]ConfigAudit, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	audits := []ConfigAudit{}
+	for i := len(repo.audits) - 1; i >= 0 && len(audits) < limit; i-- {
+		if key == "" || repo.audits[i].Key == key {
+			audits = append(audits, repo.audits[i])
+		}
+	}
+	return audits, nil
+}
+
+// addAudit 寫入一筆修改紀錄，呼叫時需要持有寫鎖
+func (repo *MemoryConfigRepository) addAudit(key, action string, oldValue, newValue *string, changedBy string) {
+	repo.audits = append(repo.audits, ConfigAudit{
+		ID:        len(repo.audits) + 1,
+		Key:       key,
+		Action:    action,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		ChangedBy: changedBy,
+		ChangedAt: time.Now(),
+	})
+}
+
+// MemoryPasswordHistoryRepository 將密碼歷史保存在記憶體中
+type MemoryPasswordHistoryRepository struct {
+	mu      sync.RWMutex
+	history map[int][]string // 依時間順序，新的在後
+}
+
+// NewMemoryPasswordHistoryRepository 建立空的記憶體 Repository
+func NewMemoryPasswordHistoryRepository() *MemoryPasswordHistoryRepository {
+	return &MemoryPasswordHistoryRepository{history: make(map[int][]string)}
+}
+
+// AddPasswordHistory 記錄用戶使用過的密碼雜湊
+func (repo *MemoryPasswordHistoryRepository) AddPasswordHistory(userID int, passwordHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.history[userID] = append(repo.history[userID], passwordHash)
+	return nil
+}
+
+// GetRecentPasswordHashes 查詢用戶最近 n 次使用過的密碼雜湊，新的在前
+func (repo *MemoryPasswordHistoryRepository) GetRecentPasswordHashes(userID, n int) ([]string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	history := repo.history[userID]
+	var hashes []string
+	for i := len(history) - 1; i >= 0 && len(hashes) < n; i-- {
+		hashes = append(hashes, history[i])
+	}
+	return hashes, nil
+}
+
+// MemoryRefreshTokenRepository 將尚未使用的 Refresh Token 保存在記憶體中
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository 建立空的記憶體 Repository
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[string]RefreshToken)}
+}
+
+// AddRefreshToken 記錄新簽發的 Refresh Token，並順便清除該用戶已過期的紀錄
+func (repo *MemoryRefreshTokenRepository) AddRefreshToken(t *RefreshToken) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	now := time.Now()
+	for jti, existing := range repo.tokens {
+		if existing.UserID == t.UserID && !existing.ExpiresAt.After(now) {
+			delete(repo.tokens, jti)
+		}
+	}
+	repo.tokens[t.JTI] = *t
+	return nil
+}
+
+// ConsumeRefreshToken 刪除未過期的 Refresh Token，不存在時返回 sql.ErrNoRows
+func (repo *MemoryRefreshTokenRepository) ConsumeRefreshToken(jti string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	t, ok := repo.tokens[jti]
+	if !ok || !t.ExpiresAt.After(time.Now()) {
+		return sql.ErrNoRows
+	}
+	delete(repo.tokens, jti)
+	return nil
+}
+
+// DeleteRefreshTokensByUser 刪除用戶所有的 Refresh Token
+func (repo *MemoryRefreshTokenRepository) DeleteRefreshTokensByUser(userID int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for jti, t := range repo.tokens {
+		if t.UserID == userID {
+			delete(repo.tokens, jti)
+		}
+	}
+	return nil
+}
+
+// MemoryAccountRepository 在記憶體中處理帳號操作
+type MemoryAccountRepository struct {
+	mu       sync.Mutex
+	users    *MemoryUserRepository
+	profiles *MemoryProfileRepository
+}
+
+// NewMemoryAccountRepository 建立操作 users 與 profiles 的記憶體 Repository
+func NewMemoryAccountRepository(users *MemoryUserRepository, profiles *MemoryProfileRepository) *MemoryAccountRepository {
+	return &MemoryAccountRepository{users: users, profiles: profiles}
+}
+
+// RegisterUser 新增用戶與用戶資訊，建立用戶資訊失敗時移除已新增的用戶
+func (repo *MemoryAccountRepository) RegisterUser(reg Registration) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if err := repo.users.AddUser(reg.Username, reg.PasswordHash, reg.RoleID); err != nil {
+		return err
+	}
+	err := repo.profiles.AddProfile(reg.Username, reg.Nickname, reg.Firstname, reg.Lastname, reg.Email, reg.Gender, reg.Birthday)
+	if err != nil {
+		repo.users.remove(reg.Username)
+		return err
+	}
+	return nil
+}
+
+// 確認記憶體實作符合介面
+var (
+	_ UserRepository            = (*MemoryUserRepository)(nil)
+	_ ProfileRepository         = (*MemoryProfileRepository)(nil)
+	_ RoleRepository            = (*MemoryRoleRepository)(nil)
+	_ ItemRepository            = (*MemoryItemRepository)(nil)
+	_ ConfigRepository          = (*MemoryConfigRepository)(nil)
+	_ PasswordHistoryRepository = (*MemoryPasswordHistoryRepository)(nil)
+	_ AccountRepository         = (*MemoryAccountRepository)(nil)
+	_ RefreshTokenRepository    = (*MemoryRefreshTokenRepository)(nil)
+)
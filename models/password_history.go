@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SQLPasswordHistoryRepository 透過 SQL 存取 password_history 資料表
+type SQLPasswordHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewSQLPasswordHistoryRepository 建立使用 db 的 Repository
+func NewSQLPasswordHistoryRepository(db *sql.DB) *SQLPasswordHistoryRepository {
+	return &SQLPasswordHistoryRepository{db: db}
+}
+
+// AddPasswordHistory 記錄用戶使用過的密碼雜湊，用於禁止重複使用舊密碼
+func (repo *SQLPasswordHistoryRepository) AddPasswordHistory(userID int, passwordHash string) error {
+	query := "INSERT INTO password_history (user_id, password_hash, created_at) VALUES (?, ?, ?)"
+	_, err := repo.db.Exec(query, userID, passwordHash, time.Now())
+	return err
+}
+
+// GetRecentPasswordHashes 查詢用戶最近 n 次使用過的密碼雜湊，新的在前
+func (repo *SQLPasswordHistoryRepository) GetRecentPasswordHashes(userID, n int) ([]string, error) {
+	query := "SELECT password_hash FROM password_history WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?"
+	rows, err := repo.db.Query(query, userID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
@@ -1,8 +1,7 @@
 package models
 
 import (
-	"fmt"
-	"http-server/database"
+	"database/sql"
 	"time"
 )
 
@@ -17,32 +16,43 @@ type Profile struct {
 	Birthday  *time.Time
 }
 
-// AddProfile 新增用戶資訊
-func AddProfile(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
-	query := "INSERT INTO profiles (username, nickname, firstname, lastname, email, gender, birthday) VALUES (?, ?, ?, ?, ?, ?, ?)"
-	_, err := database.DB.Exec(query, username, nickname, firstname, lastname, email, gender, birthday)
-	if err != nil {
-		return err
-	}
-	return nil
+// SQLProfileRepository 透過 SQL 存取 profiles 資料表
+type SQLProfileRepository struct {
+	db *sql.DB
+}
+
+// NewSQLProfileRepository 建立使用 db 的 Repository
+func NewSQLProfileRepository(db *sql.DB) *SQLProfileRepository {
+	return &SQLProfileRepository{db: db}
+}
+
+// AddProfile 新增用戶資訊，已存在時返回 ErrDuplicate
+func (repo *SQLProfileRepository) AddProfile(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
+	// user_id 從 users 表依用戶名取得，用戶不存在時 user_id 為 NULL 而新增失敗
+	// 佔位符不放在 SELECT 的欄位中，PostgreSQL 無法推斷那裡的參數類型
+	query := `
+		INSERT INTO profiles (user_id, username, nickname, firstname, lastname, email, gender, birthday)
+		VALUES ((SELECT id FROM users WHERE username = ?), ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := repo.db.Exec(query, username, username, nickname, firstname, lastname, email, gender, birthday)
+	return duplicateKeyError(err)
 }
 
 // GetProfileByUsername 根據用戶名查詢用戶資訊
-func GetProfileByUsername(username string) (*Profile, error) {
+func (repo *SQLProfileRepository) GetProfileByUsername(username string) (*Profile, error) {
 	query := "SELECT user_id, username, nickname, firstname, lastname, email, gender, birthday FROM profiles WHERE username = ?"
-	row := database.DB.QueryRow(query, username)
+	row := repo.db.QueryRow(query, username)
 
 	var profile Profile
 	err := row.Scan(&profile.UserID, &profile.Username, &profile.Nickname, &profile.Firstname, &profile.Lastname, &profile.Email, &profile.Gender, &profile.Birthday)
 	if err != nil {
-		fmt.Printf("Scan error: %v\n", err) // 輸出具體的錯誤
 		return nil, err
 	}
 	return &profile, nil
 }
 
 // UpdateProfileByUsername 根據用戶名更新用戶資訊
-func UpdateProfileByUsername(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
+func (repo *SQLProfileRepository) UpdateProfileByUsername(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error {
 	query := `
 		UPDATE profiles 
 		SET nickname = ?, 
@@ -53,10 +63,6 @@ func UpdateProfileByUsername(username, nickname, firstname, lastname, email, gen
 			birthday = ? 
 		WHERE username = ?
 	`
-	_, err := database.DB.Exec(query, nickname, firstname, lastname, email, gender, birthday, username)
-	if err != nil {
-		fmt.Printf("Update error: %v\n", err) // 輸出具體的錯誤
-		return err
-	}
-	return nil
+	_, err := repo.db.Exec(query, nickname, firstname, lastname, email, gender, birthday, username)
+	return err
 }
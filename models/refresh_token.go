@@ -0,0 +1,58 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RefreshToken 表示 refresh_tokens 資料表中的一條記錄
+// 只保存尚未使用的 Refresh Token，JTI 對應 JWT 的 jti
+type RefreshToken struct {
+	JTI       string
+	UserID    int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SQLRefreshTokenRepository 透過 SQL 存取 refresh_tokens 資料表
+type SQLRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewSQLRefreshTokenRepository 建立使用 db 的 Repository
+func NewSQLRefreshTokenRepository(db *sql.DB) *SQLRefreshTokenRepository {
+	return &SQLRefreshTokenRepository{db: db}
+}
+
+// AddRefreshToken 記錄新簽發的 Refresh Token，並順便清除該用戶已過期的紀錄
+func (repo *SQLRefreshTokenRepository) AddRefreshToken(t *RefreshToken) error {
+	if _, err := repo.db.Exec("DELETE FROM refresh_tokens WHERE user_id = ? AND expires_at <= ?", t.UserID, time.Now()); err != nil {
+		return err
+	}
+	query := "INSERT INTO refresh_tokens (jti, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)"
+	_, err := repo.db.Exec(query, t.JTI, t.UserID, t.CreatedAt, t.ExpiresAt)
+	return err
+}
+
+// ConsumeRefreshToken 刪除未過期的 Refresh Token，表示它已被使用
+// 不存在（已使用、已撤銷或已過期）時返回 sql.ErrNoRows；同一個 Token 同時被使用兩次時只有一次成功
+func (repo *SQLRefreshTokenRepository) ConsumeRefreshToken(jti string) error {
+	result, err := repo.db.Exec("DELETE FROM refresh_tokens WHERE jti = ? AND expires_at > ?", jti, time.Now())
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteRefreshTokensByUser 刪除用戶所有的 Refresh Token
+func (repo *SQLRefreshTokenRepository) DeleteRefreshTokensByUser(userID int) error {
+	_, err := repo.db.Exec("DELETE FROM refresh_tokens WHERE user_id = ?", userID)
+	return err
+}
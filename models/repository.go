@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDuplicate 表示新增的資料與現有資料的唯一鍵衝突（例如用戶名已存在）
+var ErrDuplicate = errors.New("duplicate key")
+
+// 以下介面描述 controllers 所需的資料存取操作，
+// SQL* 為 MySQL 實作，Memory* 為記憶體實作（用於測試與不連線資料庫的展示模式）。
+// 查詢單筆資料但資料不存在時，所有實作都返回 sql.ErrNoRows。
+
+// UserRepository 存取用戶帳號
+type UserRepository interface {
+	AddUser(username, passwordHash, roleID string) error
+	GetUserByUsername(username string) (*User, error)
+	ChangePasswordByUsername(username, passwordHash string) error
+}
+
+// ProfileRepository 存取用戶資訊
+type ProfileRepository interface {
+	AddProfile(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error
+	GetProfileByUsername(username string) (*Profile, error)
+	UpdateProfileByUsername(username, nickname, firstname, lastname, email, gender string, birthday *time.Time) error
+}
+
+// RoleRepository 存取角色與權限
+type RoleRepository interface {
+	GetRoleById(id string) (*Role, error)
+}
+
+// ItemRepository 存取 items
+type ItemRepository interface {
+	QueryItems(q ItemQuery) ([]Item, int, bool, error)
+	GetItemByID(id int) (*Item, error)
+	AddItem(value string) (int, error)
+	DeleteItem(id int) error
+	UpdateItem(id int, value string) error
+}
+
+// AccountRepository 處理需要同時修改多個資料表的帳號操作
+type AccountRepository interface {
+	RegisterUser(reg Registration) error
+}
+
+// ConfigRepository 存取 config 表中的配置，寫入時一併記錄修改紀錄
+type ConfigRepository interface {
+	GetAllConfigs() ([]ConfigEntry, error)
+	GetConfig(key string) (*ConfigEntry, error)
+	AddConfig(key, value, changedBy string) error
+	UpdateConfig(key, value, changedBy string) error
+	DeleteConfig(key, changedBy string) error
+	GetConfigAudit(key string, limit int) ([]ConfigAudit, error)
+}
+
+// PasswordHistoryRepository 存取用戶使用過的密碼雜湊
+type PasswordHistoryRepository interface {
+	AddPasswordHistory(userID int, passwordHash string) error
+	GetRecentPasswordHashes(userID, n int) ([]string, error)
+}
+
+// RefreshTokenRepository 存取尚未使用的 Refresh Token，用於輪替與撤銷 Refresh Token
+type RefreshTokenRepository interface {
+	AddRefreshToken(t *RefreshToken) error
+	ConsumeRefreshToken(jti string) error
+	DeleteRefreshTokensByUser(userID int) error
+}
+
+// 確認 SQL 實作符合介面
+var (
+	_ UserRepository            = (*SQLUserRepository)(nil)
+	_ ProfileRepository         = (*SQLProfileRepository)(nil)
+	_ RoleRepository            = (*SQLRoleRepository)(nil)
+	_ ItemRepository            = (*SQLItemRepository)(nil)
+	_ ConfigRepository          = (*SQLConfigRepository)(nil)
+	_ PasswordHistoryRepository = (*SQLPasswordHistoryRepository)(nil)
+	_ AccountRepository         = (*SQLAccountRepository)(nil)
+	_ RefreshTokenRepository    = (*SQLRefreshTokenRepository)(nil)
+)
@@ -1,24 +1,71 @@
 package models
 
 import (
-	"http-server/database"
+	"database/sql"
 )
 
 type Role struct {
 	ID          int
 	Name        string
 	Description string
+	Permissions []string // 角色擁有的權限，來自 role_permissions 表
 }
 
-// GetRoleById 查詢用戶角色
-func GetRoleById(id string) (*Role, error) {
+// SQLRoleRepository 透過 SQL 存取 roles 與 role_permissions 資料表
+type SQLRoleRepository struct {
+	db *sql.DB
+}
+
+// NewSQLRoleRepository 建立使用 db 的 Repository
+func NewSQLRoleRepository(db *sql.DB) *SQLRoleRepository {
+	return &SQLRoleRepository{db: db}
+}
+
+// GetRoleById 查詢用戶角色，並一併載入該角色的權限列表
+func (repo *SQLRoleRepository) GetRoleById(id string) (*Role, error) {
 	query := "SELECT id, name, description FROM roles WHERE id = ?"
-	row := database.DB.QueryRow(query, id)
+	row := repo.db.QueryRow(query, id)
 
 	var role Role
 	err := row.Scan(&role.ID, &role.Name, &role.Description)
 	if err != nil {
 		return nil, err
 	}
+
+	permissions, err := repo.getPermissionsByRoleId(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
 	return &role, nil
 }
+
+// HasPermission 判斷角色是否擁有指定權限
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// getPermissionsByRoleId 查詢角色對應的權限（role_permissions 表：role_id, permission）
+func (repo *SQLRoleRepository) getPermissionsByRoleId(roleID int) ([]string, error) {
+	query := "SELECT permission FROM role_permissions WHERE role_id = ?"
+	rows, err := repo.db.Query(query, roleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"http-server/database"
+	"time"
+)
+
+// Session 表示 sessions 資料表中的一條記錄
+// Data 為序列化後的 Session 內容，Username 用來查詢與撤銷某位用戶的所有 Session
+type Session struct {
+	ID        string
+	Username  string
+	Data      []byte
+	UserAgent string
+	IP        string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SQLSessionRepository 透過 SQL 存取 sessions 資料表
+type SQLSessionRepository struct {
+	db *sql.DB
+}
+
+// NewSQLSessionRepository 建立使用 db 的 Repository
+func NewSQLSessionRepository(db *sql.DB) *SQLSessionRepository {
+	return &SQLSessionRepository{db: db}
+}
+
+// SaveSession 新增或更新一筆 Session
+func (repo *SQLSessionRepository) SaveSession(s *Session) error {
+	query := `
+		INSERT INTO sessions (id, username, data, user_agent, ip, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			username = VALUES(username),
+			data = VALUES(data),
+			user_agent = VALUES(user_agent),
+			ip = VALUES(ip),
+			expires_at = VALUES(expires_at)
+	`
+	if database.DialectOf(repo.db) != database.MySQL {
+		// PostgreSQL 與 SQLite 使用 ON CONFLICT
+		query = `
+			INSERT INTO sessions (id, username, data, user_agent, ip, created_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				username = excluded.username,
+				data = excluded.data,
+				user_agent = excluded.user_agent,
+				ip = excluded.ip,
+				expires_at = excluded.expires_at
+		`
+	}
+	_, err := repo.db.Exec(query, s.ID, s.Username, s.Data, s.UserAgent, s.IP, s.CreatedAt, s.ExpiresAt)
+	return err
+}
+
+// GetSessionByID 根據 ID 查詢未過期的 Session
+func (repo *SQLSessionRepository) GetSessionByID(id string) (*Session, error) {
+	query := "SELECT id, username, data, user_agent, ip, created_at, expires_at FROM sessions WHERE id = ? AND expires_at > ?"
+	row := repo.db.QueryRow(query, id, time.Now())
+
+	var s Session
+	err := row.Scan(&s.ID, &s.Username, &s.Data, &s.UserAgent, &s.IP, &s.CreatedAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetSessionsByUsername 查詢用戶所有未過期的 Session
+func (repo *SQLSessionRepository) GetSessionsByUsername(username string) ([]Session, error) {
+	query := "SELECT id, username, data, user_agent, ip, created_at, expires_at FROM sessions WHERE username = ? AND expires_at > ? ORDER BY created_at"
+	rows, err := repo.db.Query(query, username, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Session
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.Username, &s.Data, &s.UserAgent, &s.IP, &s.CreatedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// Ping 確認 sessions 資料表可以查詢
+func (repo *SQLSessionRepository) Ping(ctx context.Context) error {
+	var one int
+	err := repo.db.QueryRowContext(ctx, "SELECT 1 FROM sessions LIMIT 1").Scan(&one)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// DeleteSession 根據 ID 刪除 Session
+func (repo *SQLSessionRepository) DeleteSession(id string) error {
+	_, err := repo.db.Exec("DELETE FROM sessions WHERE id = ?", id)
+	return err
+}
+
+// DeleteSessionsByUsername 刪除用戶所有的 Session，exceptID 不為空時保留該筆
+func (repo *SQLSessionRepository) DeleteSessionsByUsername(username, exceptID string) error {
+	_, err := repo.db.Exec("DELETE FROM sessions WHERE username = ? AND id <> ?", username, exceptID)
+	return err
+}
+
+// DeleteExpiredSessions 清除所有已過期的 Session
+func (repo *SQLSessionRepository) DeleteExpiredSessions() error {
+	_, err := repo.db.Exec("DELETE FROM sessions WHERE expires_at <= ?", time.Now())
+	return err
+}
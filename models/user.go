@@ -1,8 +1,7 @@
 package models
 
 import (
-	"fmt"
-	"http-server/database"
+	"database/sql"
 )
 
 // User 表示 users 資料表中的一條記錄
@@ -11,42 +10,46 @@ type User struct {
 	Username     string
 	PasswordHash string
 	RoleID       string
+	TokenVersion int // 增加後，之前簽發的 JWT 全部失效
 }
 
-// AddUser 新增用戶
-func AddUser(username, passwordHash, roleID string) error {
+// SQLUserRepository 透過 SQL 存取 users 資料表
+type SQLUserRepository struct {
+	db *sql.DB
+}
+
+// NewSQLUserRepository 建立使用 db 的 Repository
+func NewSQLUserRepository(db *sql.DB) *SQLUserRepository {
+	return &SQLUserRepository{db: db}
+}
+
+// AddUser 新增用戶，用戶名已存在時返回 ErrDuplicate
+func (repo *SQLUserRepository) AddUser(username, passwordHash, roleID string) error {
 	query := "INSERT INTO users (username, password_hash, role_id) VALUES (?, ?, ?)"
-	_, err := database.DB.Exec(query, username, passwordHash, roleID)
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := repo.db.Exec(query, username, passwordHash, roleID)
+	return duplicateKeyError(err)
 }
 
 // GetUserByUsername 根據用戶名查詢用戶
-func GetUserByUsername(username string) (*User, error) {
-	query := "SELECT id, username, password_hash, role_id FROM users WHERE username = ?"
-	row := database.DB.QueryRow(query, username)
+func (repo *SQLUserRepository) GetUserByUsername(username string) (*User, error) {
+	query := "SELECT id, username, password_hash, role_id, token_version FROM users WHERE username = ?"
+	row := repo.db.QueryRow(query, username)
 
 	var user User
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.RoleID)
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.RoleID, &user.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// ChangePasswordByUsername 根據用戶名更新密碼
-func ChangePasswordByUsername(username, passwordHash string) error {
+// ChangePasswordByUsername 根據用戶名更新密碼，並增加 token_version 使之前簽發的 JWT 全部失效
+func (repo *SQLUserRepository) ChangePasswordByUsername(username, passwordHash string) error {
 	query := `
 		UPDATE users
-		SET password_hash = ?
+		SET password_hash = ?, token_version = token_version + 1
 		WHERE username = ?
 	`
-	_, err := database.DB.Exec(query, passwordHash, username)
-	if err != nil {
-		fmt.Printf("Update error: %v\n", err) // 輸出具體的錯誤
-		return err
-	}
-	return nil
+	_, err := repo.db.Exec(query, passwordHash, username)
+	return err
 }
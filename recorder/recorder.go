@@ -0,0 +1,50 @@
+// Package recorder 包裝 http.ResponseWriter，記錄響應的狀態碼與大小，供日誌與指標的中介層共用。
+package recorder
+
+import "net/http"
+
+// Recorder 記錄響應的狀態碼與寫入的位元組數
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+// Wrap 返回記錄 w 的 Recorder；w 已經是 Recorder 時直接返回，多個中介層共用同一份紀錄
+func Wrap(w http.ResponseWriter) *Recorder {
+	if rec, ok := w.(*Recorder); ok {
+		return rec
+	}
+	return &Recorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status 返回響應的狀態碼，未呼叫 WriteHeader 時為 200
+func (rec *Recorder) Status() int {
+	return rec.status
+}
+
+// Bytes 返回已寫入響應的位元組數
+func (rec *Recorder) Bytes() int {
+	return rec.bytes
+}
+
+func (rec *Recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *Recorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap 讓 http.ResponseController 可以取得原本的 ResponseWriter（例如 Flush）
+func (rec *Recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -1,21 +1,27 @@
 package routes
 
 import (
+	"http-server/app"
 	// 匯入控制器
 	"http-server/controllers"
+	"http-server/logging"
 	"net/http"
 	"os"
 	"path/filepath"
 )
 
-func Routes() {
+// Routes 建立包含所有路由的 http.Handler，處理函數透過 a 取得依賴
+func Routes(a *app.App) http.Handler {
+	mux := http.NewServeMux()
+	c := controllers.New(a)
+
 	// 獲取當前工作目錄
 	workingDir, _ := os.Getwd()
 	// 拼接出靜態文件的絕對路徑，假設靜態文件存放在 "public" 資料夾內
 	staticPath := filepath.Join(workingDir, "public")
 
 	// 註冊一個處理所有 HTTP 請求的處理函數
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// 拼接請求的路徑到靜態文件目錄，得到請求的完整文件路徑
 		filePath := filepath.Join(staticPath, r.URL.Path)
 
@@ -30,23 +36,84 @@ func Routes() {
 		}
 	})
 
-	itemRoutes()
-	authRoutes()
+	healthRoutes(mux, c)
+	itemRoutes(mux, c)
+	authRoutes(mux, c)
+	adminRoutes(mux, c)
+
+	// 記錄所有請求的次數、耗時與響應大小，並為每個請求指定 Request ID 與記錄存取日誌
+	return logging.Middleware(a.Metrics.Middleware(mux))
+}
+
+func healthRoutes(mux *http.ServeMux, c *controllers.Controller) {
+	// 供負載平衡與容器編排探測，不需要登入，也不會落入靜態文件的處理函數
+	mux.HandleFunc("/healthz", c.LivenessHandler)
+	mux.HandleFunc("/readyz", c.ReadinessHandler)
+	// 指標中有路由、登入失敗次數與連線池統計，需要 admin 權限
+	// Prometheus 抓取時使用 METRICS_ADDR 另外監聽的位址
+	mux.Handle("/metrics", c.RequirePermission(controllers.PermAdmin)(c.Metrics.Handler()))
+}
+
+func itemRoutes(mux *http.ServeMux, c *controllers.Controller) {
+	// 讀取需要 items:read 權限，寫入需要 items:write 權限
+	// 未登入時返回 401，不會重定向到登入頁面
+	canRead := c.RequirePermission(controllers.PermItemsRead)
+	canWrite := c.RequirePermission(controllers.PermItemsWrite)
+
+	mux.Handle("GET /api/items", canRead(http.HandlerFunc(c.GetItemsHandler)))
+	mux.Handle("POST /api/items", canWrite(http.HandlerFunc(c.AddItemHandler)))
+	mux.Handle("GET /api/items/{id}", canRead(http.HandlerFunc(c.GetItemHandler)))
+	mux.Handle("PUT /api/items/{id}", canWrite(http.HandlerFunc(c.UpdateItemHandler)))
+	mux.Handle("PATCH /api/items/{id}", canWrite(http.HandlerFunc(c.PatchItemHandler)))
+	mux.Handle("DELETE /api/items/{id}", canWrite(http.HandlerFunc(c.DeleteItemHandler)))
+
+	// 舊路徑，保留給尚未更新的客戶端使用
+	mux.Handle("POST /api/items/add", deprecated("/api/items", canWrite(http.HandlerFunc(c.AddItemHandler))))
+	mux.Handle("DELETE /api/items/delete/{id}", deprecated("/api/items/{id}", canWrite(http.HandlerFunc(c.DeleteItemHandler))))
+	mux.Handle("PUT /api/items/update/{id}", deprecated("/api/items/{id}", canWrite(http.HandlerFunc(c.UpdateItemHandler))))
 }
 
-func itemRoutes() {
-	http.HandleFunc("/api/items", controllers.GetItemsHandler)
-	http.HandleFunc("/api/items/add", controllers.AddItemHandler)
-	http.HandleFunc("/api/items/delete/", controllers.DeleteItemHandler)
-	http.HandleFunc("/api/items/update/", controllers.UpdateItemHandler)
+// deprecated 標記已棄用的路徑，並透過 Link 標頭指向新的路徑
+func deprecated(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
 }
 
-func authRoutes() {
-	http.HandleFunc("/auth/register", controllers.RegisterHandler)
-	http.HandleFunc("/auth/login", controllers.LoginHandler)
-	http.HandleFunc("/auth/logout", controllers.LogoutHandler)
-	http.HandleFunc("/auth/profile/", controllers.ProfileHandler)
-	http.HandleFunc("/auth/profile/update/", controllers.UpdateProfileHandler)
-	http.HandleFunc("/auth/change-password/", controllers.ChangePasswordHandler)
-	http.Handle("/auth/me", controllers.Authenticate(http.HandlerFunc(controllers.MeHandler)))
+func authRoutes(mux *http.ServeMux, c *controllers.Controller) {
+	// 修改資料與密碼需要登入且擁有 profile:write 權限
+	canEditProfile := func(next http.Handler) http.Handler {
+		return c.Authenticate(c.RequirePermission(controllers.PermProfileWrite)(next))
+	}
+
+	mux.HandleFunc("/auth/register", c.RegisterHandler)
+	mux.HandleFunc("/auth/login", c.LoginHandler)
+	mux.HandleFunc("/auth/logout", c.LogoutHandler)
+	mux.HandleFunc("/auth/token/refresh", c.RefreshTokenHandler)
+	mux.HandleFunc("/auth/profile/", c.ProfileHandler)
+	mux.Handle("/auth/profile/update/", canEditProfile(http.HandlerFunc(c.UpdateProfileHandler)))
+	mux.Handle("/auth/change-password/", canEditProfile(http.HandlerFunc(c.ChangePasswordHandler)))
+	mux.Handle("/auth/me", c.Authenticate(http.HandlerFunc(c.MeHandler)))
+	mux.Handle("/auth/sessions", c.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			c.RevokeOtherSessionsHandler(w, r)
+			return
+		}
+		c.ListSessionsHandler(w, r)
+	})))
+	mux.Handle("/auth/sessions/", c.Authenticate(http.HandlerFunc(c.RevokeSessionHandler)))
+}
+
+func adminRoutes(mux *http.ServeMux, c *controllers.Controller) {
+	// 管理 API 需要 admin 權限
+	isAdmin := c.RequirePermission(controllers.PermAdmin)
+
+	mux.Handle("GET /api/admin/config", isAdmin(http.HandlerFunc(c.ListConfigsHandler)))
+	mux.Handle("POST /api/admin/config", isAdmin(http.HandlerFunc(c.CreateConfigHandler)))
+	mux.Handle("GET /api/admin/config/{key}", isAdmin(http.HandlerFunc(c.GetConfigHandler)))
+	mux.Handle("PUT /api/admin/config/{key}", isAdmin(http.HandlerFunc(c.UpdateConfigHandler)))
+	mux.Handle("DELETE /api/admin/config/{key}", isAdmin(http.HandlerFunc(c.DeleteConfigHandler)))
+	mux.Handle("GET /api/admin/config-audit", isAdmin(http.HandlerFunc(c.ListConfigAuditHandler)))
 }
@@ -0,0 +1,90 @@
+package sessionstore
+
+import (
+	"context"
+	"http-server/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore 將 Session 保存在記憶體中，適合測試與單機開發使用
+// 伺服器重啟後所有 Session 都會失效
+type MemoryStore struct {
+	*store
+}
+
+// NewMemoryStore 建立記憶體 Session Store，keyPairs 用於簽名與加密 Cookie 中的 Session ID
+func NewMemoryStore(keyPairs ...[]byte) *MemoryStore {
+	return &MemoryStore{store: newStore(&memoryBackend{sessions: make(map[string]models.Session)}, keyPairs...)}
+}
+
+type memoryBackend struct {
+	sessions map[string]models.Session
+	mu       sync.RWMutex
+}
+
+func (m *memoryBackend) load(id string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[id]
+	if !ok || !s.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *memoryBackend) save(s *models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *memoryBackend) delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *memoryBackend) listByUser(username string) ([]models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := time.Now()
+	var list []models.Session
+	for _, s := range m.sessions {
+		if s.Username == username && s.ExpiresAt.After(now) {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (m *memoryBackend) deleteByUser(username, exceptID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.sessions {
+		if s.Username == username && id != exceptID {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (m *memoryBackend) ping(context.Context) error {
+	return nil
+}
+
+func (m *memoryBackend) deleteExpired() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, s := range m.sessions {
+		if !s.ExpiresAt.After(now) {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"http-server/models"
+)
+
+// SQLStore 將 Session 保存在資料庫的 sessions 資料表，MySQL、PostgreSQL 與 SQLite 都適用
+type SQLStore struct {
+	*store
+}
+
+// NewSQLStore 建立使用 sessions 資料表的 Session Store，keyPairs 用於簽名與加密 Cookie 中的 Session ID
+func NewSQLStore(repo *models.SQLSessionRepository, keyPairs ...[]byte) *SQLStore {
+	return &SQLStore{store: newStore(sqlBackend{repo: repo}, keyPairs...)}
+}
+
+// sqlBackend 透過 models 存取 sessions 資料表
+type sqlBackend struct {
+	repo *models.SQLSessionRepository
+}
+
+func (b sqlBackend) load(id string) (*models.Session, error) {
+	s, err := b.repo.GetSessionByID(id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (b sqlBackend) save(s *models.Session) error {
+	return b.repo.SaveSession(s)
+}
+
+func (b sqlBackend) delete(id string) error {
+	return b.repo.DeleteSession(id)
+}
+
+func (b sqlBackend) listByUser(username string) ([]models.Session, error) {
+	return b.repo.GetSessionsByUsername(username)
+}
+
+func (b sqlBackend) deleteByUser(username, exceptID string) error {
+	return b.repo.DeleteSessionsByUsername(username, exceptID)
+}
+
+func (b sqlBackend) ping(ctx context.Context) error {
+	return b.repo.Ping(ctx)
+}
+
+func (b sqlBackend) deleteExpired() error {
+	return b.repo.DeleteExpiredSessions()
+}
@@ -0,0 +1,271 @@
+// Package sessionstore 提供伺服器端保存的 gorilla Session Store。
+// Cookie 中只保存簽名後的 Session ID，內容保存在後端（資料庫或記憶體），
+// 因此可以列出用戶目前登入中的 Session，並隨時撤銷。
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/gob"
+	"errors"
+	"http-server/models"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// Store 是伺服器端 Session Store，除了 gorilla 的 sessions.Store 之外還支援查詢與撤銷
+type Store interface {
+	sessions.Store
+
+	// ListUserSessions 列出用戶所有未過期的 Session
+	ListUserSessions(username string) ([]SessionInfo, error)
+	// Revoke 撤銷用戶的某一個 Session
+	Revoke(username, id string) error
+	// RevokeAll 撤銷用戶所有的 Session，exceptID 不為空時保留該筆（通常是當前 Session）
+	RevokeAll(username, exceptID string) error
+	// Regenerate 刪除 Session 原本的紀錄並清空內容，下次 Save 時會產生新的 Session ID
+	Regenerate(session *sessions.Session) error
+	// Ping 確認 Session 的儲存位置可以使用，供健康檢查使用
+	Ping(ctx context.Context) error
+	// Close 停止背景清理工作
+	Close() error
+}
+
+// ErrNotFound 表示 Session 不存在、已過期，或不屬於該用戶
+var ErrNotFound = errors.New("session not found")
+
+// SessionInfo 是提供給用戶查看的 Session 資訊，不包含 Session 內容
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// backend 是實際保存 Session 的地方
+// load 在找不到或已過期時返回 (nil, nil)
+type backend interface {
+	load(id string) (*models.Session, error)
+	save(s *models.Session) error
+	delete(id string) error
+	listByUser(username string) ([]models.Session, error)
+	deleteByUser(username, exceptID string) error
+	deleteExpired() error
+	ping(ctx context.Context) error
+}
+
+// store 實作 Store，負責 Cookie 的編碼與 Session 的序列化，資料存取交給 backend
+type store struct {
+	backend backend
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// cleanupInterval 是清除過期 Session 的間隔
+const cleanupInterval = 10 * time.Minute
+
+func newStore(b backend, keyPairs ...[]byte) *store {
+	s := &store{
+		backend: b,
+		Codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		stop: make(chan struct{}),
+	}
+	go s.cleanup()
+	return s
+}
+
+// Get 返回此請求中指定名稱的 Session，同一個請求內會重複使用同一個 Session
+func (s *store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New 建立 Session，若 Cookie 中的 Session 仍有效則載入其內容
+func (s *store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, err
+	}
+
+	record, err := s.backend.load(session.ID)
+	if err != nil {
+		session.ID = ""
+		return session, err
+	}
+	if record == nil {
+		// 已過期或已被撤銷，當作新的 Session，保存時會產生新的 ID
+		session.ID = ""
+		return session, nil
+	}
+	if err := gob.NewDecoder(bytes.NewReader(record.Data)).Decode(&session.Values); err != nil {
+		session.ID = ""
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save 保存 Session 並寫入 Cookie；MaxAge 小於等於 0 時刪除 Session
+func (s *store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			if err := s.backend.delete(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	now := time.Now()
+	record := &models.Session{
+		ID:        session.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(session.Options.MaxAge) * time.Second),
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+	}
+	if record.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		record.ID = id
+	} else if existing, err := s.backend.load(record.ID); err != nil {
+		return err
+	} else if existing != nil {
+		record.CreatedAt = existing.CreatedAt
+	}
+	record.Username, _ = session.Values["username"].(string)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(session.Values); err != nil {
+		return err
+	}
+	record.Data = buf.Bytes()
+
+	if err := s.backend.save(record); err != nil {
+		return err
+	}
+	session.ID = record.ID
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func (s *store) ListUserSessions(username string) ([]SessionInfo, error) {
+	records, err := s.backend.listByUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]SessionInfo, 0, len(records))
+	for _, record := range records {
+		list = append(list, SessionInfo{
+			ID:        record.ID,
+			UserAgent: record.UserAgent,
+			IP:        record.IP,
+			CreatedAt: record.CreatedAt,
+			ExpiresAt: record.ExpiresAt,
+		})
+	}
+	return list, nil
+}
+
+func (s *store) Revoke(username, id string) error {
+	record, err := s.backend.load(id)
+	if err != nil {
+		return err
+	}
+	// 只能撤銷屬於該用戶的 Session
+	if record == nil || record.Username != username {
+		return ErrNotFound
+	}
+	return s.backend.delete(id)
+}
+
+func (s *store) RevokeAll(username, exceptID string) error {
+	return s.backend.deleteByUser(username, exceptID)
+}
+
+// Regenerate 在登入時使用，避免沿用登入前由 Cookie 帶入的 Session ID（Session Fixation）
+func (s *store) Regenerate(session *sessions.Session) error {
+	if session.ID != "" {
+		if err := s.backend.delete(session.ID); err != nil {
+			return err
+		}
+	}
+	session.ID = ""
+	session.IsNew = true
+	session.Values = make(map[interface{}]interface{})
+	return nil
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	return s.backend.ping(ctx)
+}
+
+func (s *store) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	return nil
+}
+
+// cleanup 定期清除過期的 Session
+func (s *store) cleanup() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.backend.deleteExpired()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// newSessionID 產生隨機的 Session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(b), "="), nil
+}
+
+// clientIP 取得請求來源 IP
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}